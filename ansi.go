@@ -0,0 +1,193 @@
+package pprint
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Color is a terminal color. The zero value is the terminal's default color.
+type Color struct {
+	kind  colorKind
+	value uint32
+}
+
+type colorKind uint8
+
+const (
+	colorDefault colorKind = iota
+	color16
+	color256
+	colorRGB
+)
+
+// The 16 standard terminal colors.
+var (
+	Black         = ANSIColor(0)
+	Red           = ANSIColor(1)
+	Green         = ANSIColor(2)
+	Yellow        = ANSIColor(3)
+	Blue          = ANSIColor(4)
+	Magenta       = ANSIColor(5)
+	Cyan          = ANSIColor(6)
+	White         = ANSIColor(7)
+	BrightBlack   = ANSIColor(8)
+	BrightRed     = ANSIColor(9)
+	BrightGreen   = ANSIColor(10)
+	BrightYellow  = ANSIColor(11)
+	BrightBlue    = ANSIColor(12)
+	BrightMagenta = ANSIColor(13)
+	BrightCyan    = ANSIColor(14)
+	BrightWhite   = ANSIColor(15)
+)
+
+// ANSIColor returns one of the 16 standard terminal colors. Colors 8 to 15 are the bright variants of 0 to 7.
+func ANSIColor(n uint8) Color {
+	return Color{kind: color16, value: uint32(n % 16)}
+}
+
+// Color256 returns a color of the 256-color palette.
+func Color256(n uint8) Color {
+	return Color{kind: color256, value: uint32(n)}
+}
+
+// RGB returns a 24-bit true color.
+func RGB(r, g, b uint8) Color {
+	return Color{kind: colorRGB, value: uint32(r)<<16 | uint32(g)<<8 | uint32(b)}
+}
+
+// sgr appends the SGR parameters selecting the color, using base 30 for the foreground and 40 for the background.
+func (c Color) sgr(params []string, base int) []string {
+	switch c.kind {
+	case color16:
+		if c.value < 8 {
+			return append(params, strconv.Itoa(base+int(c.value)))
+		}
+
+		return append(params, strconv.Itoa(base+60+int(c.value)-8))
+	case color256:
+		return append(params, strconv.Itoa(base+8), "5", strconv.Itoa(int(c.value)))
+	case colorRGB:
+		return append(params,
+			strconv.Itoa(base+8), "2",
+			strconv.Itoa(int(c.value>>16&0xff)),
+			strconv.Itoa(int(c.value>>8&0xff)),
+			strconv.Itoa(int(c.value&0xff)),
+		)
+	default:
+		return params
+	}
+}
+
+// Style is a set of terminal text attributes.
+// Zero fields leave the attribute of the surrounding style unchanged.
+type Style struct {
+	Foreground Color
+	Background Color
+	Bold       bool
+	Italic     bool
+	Underline  bool
+}
+
+// Styled annotates the document with the style, for `DisplayANSI`.
+func Styled(style Style, doc Doc) Doc {
+	return Annotate(style, doc)
+}
+
+// over returns the style s applied inside the style outer.
+func (s Style) over(outer Style) Style {
+	if s.Foreground.kind == colorDefault {
+		s.Foreground = outer.Foreground
+	}
+	if s.Background.kind == colorDefault {
+		s.Background = outer.Background
+	}
+	s.Bold = s.Bold || outer.Bold
+	s.Italic = s.Italic || outer.Italic
+	s.Underline = s.Underline || outer.Underline
+
+	return s
+}
+
+// escape returns the SGR escape sequence that resets the terminal and then selects the style.
+func (s Style) escape() string {
+	params := []string{"0"}
+	if s.Bold {
+		params = append(params, "1")
+	}
+	if s.Italic {
+		params = append(params, "3")
+	}
+	if s.Underline {
+		params = append(params, "4")
+	}
+	params = s.Foreground.sgr(params, 30)
+	params = s.Background.sgr(params, 40)
+
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// DisplayANSI writes the rendered SimpleDoc to the given writer like `Display`,
+// turning the styles attached by `Styled` into ANSI escape sequences.
+// Nested styles are combined with the styles around them, and the outer style is restored when a nested one ends.
+// Annotations other than `Style` are ignored.
+//
+// If the NO_COLOR environment variable is set to a non-empty value, no escape sequences are written.
+func DisplayANSI(w io.Writer, x SimpleDoc) error {
+	if os.Getenv("NO_COLOR") != "" {
+		return Display(w, x)
+	}
+
+	var (
+		out     = newSink(w)
+		styles  = []Style{{}}
+		current Style
+	)
+
+	setStyle := func(s Style) {
+		if s != current {
+			current = s
+			out.writeString(s.escape())
+		}
+	}
+
+	for out.err == nil {
+		switch d := x.(type) {
+		case SEmpty:
+			setStyle(Style{})
+			_, err := out.flush()
+
+			return err
+		case SChar:
+			out.writeRune(d.char)
+			x = d.rest
+		case SText:
+			out.writeString(d.text)
+			x = d.rest
+		case SLine:
+			out.writeLine(d)
+			x = d.rest
+		case SAnnPush:
+			outer := styles[len(styles)-1]
+			style := outer
+			if s, ok := d.ann.(Style); ok {
+				style = s.over(outer)
+			}
+			styles = append(styles, style)
+			setStyle(style)
+			x = d.rest
+		case SAnnPop:
+			if len(styles) > 1 {
+				styles = styles[:len(styles)-1]
+			}
+			setStyle(styles[len(styles)-1])
+			x = d.rest
+		default:
+			panic(fmt.Sprintf("unexpected pprint.SimpleDoc: %#v", d))
+		}
+	}
+
+	return out.err
+}
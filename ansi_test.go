@@ -0,0 +1,89 @@
+package pprint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takoeight0821/pprint"
+)
+
+func TestDisplayANSI(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	keyword := pprint.Style{Foreground: pprint.Blue, Bold: true}
+	str := pprint.Style{Foreground: pprint.RGB(255, 128, 0)}
+	errorStyle := pprint.Style{Background: pprint.Color256(196), Underline: true}
+
+	tests := []struct {
+		name string
+		doc  pprint.Doc
+		want string
+	}{
+		{
+			name: "Plain",
+			doc:  pprint.Text("plain"),
+			want: "plain",
+		},
+		{
+			name: "Single Style",
+			doc:  pprint.Hsep(pprint.Styled(keyword, pprint.Text("func")), pprint.Text("main")),
+			want: "\x1b[0;1;34mfunc\x1b[0m main",
+		},
+		{
+			name: "Nested Styles",
+			doc: pprint.Styled(errorStyle, pprint.Hsep(
+				pprint.Text("bad"),
+				pprint.Styled(str, pprint.Text(`"x"`)),
+				pprint.Text("here"),
+			)),
+			want: "\x1b[0;4;48;5;196mbad \x1b[0;4;38;2;255;128;0;48;5;196m\"x\"\x1b[0;4;48;5;196m here\x1b[0m",
+		},
+		{
+			name: "Bright And Italic",
+			doc:  pprint.Styled(pprint.Style{Foreground: pprint.BrightRed, Italic: true}, pprint.Text("!")),
+			want: "\x1b[0;3;91m!\x1b[0m",
+		},
+		{
+			name: "Other Annotations",
+			doc:  pprint.Annotate("node", pprint.Styled(keyword, pprint.Text("if"))),
+			want: "\x1b[0;1;34mif\x1b[0m",
+		},
+		{
+			name: "Styles Do Not Count Toward Width",
+			doc: pprint.Hsep(
+				pprint.Fill(6, pprint.Styled(keyword, pprint.Text("var"))),
+				pprint.Text("x"),
+			),
+			want: "\x1b[0;1;34mvar\x1b[0m    x",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got strings.Builder
+			if err := pprint.DisplayANSI(&got, pprint.RenderPretty(1, 80, test.doc)); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, got.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDisplayANSI_NoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	doc := pprint.Hsep(pprint.Styled(pprint.Style{Bold: true}, pprint.Text("func")), pprint.Text("main"))
+
+	var got strings.Builder
+	if err := pprint.DisplayANSI(&got, pprint.RenderPretty(1, 80, doc)); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff("func main", got.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,199 @@
+package pprint
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+type stringWriter interface {
+	io.Writer
+	io.StringWriter
+}
+
+// sink is the destination of the display functions.
+// It writes directly to writers that keep the output in memory, and through a `bufio.Writer` otherwise,
+// so that writing to a file or a terminal does not make a system call for every token.
+// The first error stops all further writes and is returned by flush.
+type sink struct {
+	w   stringWriter
+	buf *bufio.Writer
+	n   int64
+	err error
+	r   [utf8.UTFMax]byte
+}
+
+func newSink(w io.Writer) *sink {
+	switch w := w.(type) {
+	case *strings.Builder:
+		return &sink{w: w}
+	case *bytes.Buffer:
+		return &sink{w: w}
+	case *bufio.Writer:
+		return &sink{w: w}
+	}
+
+	buf := bufio.NewWriter(w)
+
+	return &sink{w: buf, buf: buf}
+}
+
+func (s *sink) writeString(str string) {
+	if s.err != nil {
+		return
+	}
+
+	n, err := s.w.WriteString(str)
+	s.n += int64(n)
+	s.err = err
+}
+
+func (s *sink) writeRune(r rune) {
+	if s.err != nil {
+		return
+	}
+
+	n, err := s.w.Write(s.r[:utf8.EncodeRune(s.r[:], r)])
+	s.n += int64(n)
+	s.err = err
+}
+
+// writeLine writes the line break and the indentation after it.
+func (s *sink) writeLine(l SLine) {
+	tabs, spaces := l.split()
+	if tabs == 0 && spaces < len(newlineSpaces) {
+		s.writeString(newlineSpaces[:1+spaces])
+		return
+	}
+
+	s.writeString("\n")
+	s.writeRepeated(tabIndentation, tabs)
+	s.writeRepeated(newlineSpaces[1:], spaces)
+}
+
+// writeRepeated writes n times the byte that cache is full of.
+func (s *sink) writeRepeated(cache string, n int) {
+	for n > 0 {
+		m := min(n, len(cache))
+		s.writeString(cache[:m])
+		n -= m
+	}
+}
+
+// flush writes out the buffered output and returns the number of bytes written and the first error.
+func (s *sink) flush() (int64, error) {
+	if s.err == nil && s.buf != nil {
+		s.err = s.buf.Flush()
+	}
+
+	return s.n, s.err
+}
+
+// The cached indentation, so that writing a line break does not allocate.
+var (
+	newlineSpaces  = "\n" + strings.Repeat(" ", 256)
+	tabIndentation = strings.Repeat("\t", 64)
+)
+
+// split returns the number of tabs and spaces of the indentation after the line break.
+// A negative indentation, such as from `Nest` with a negative amount, is written as none.
+func (l SLine) split() (tabs, spaces int) {
+	indent := max(l.indent, 0)
+	if l.tab > 0 {
+		return indent / l.tab, indent % l.tab
+	}
+
+	return 0, indent
+}
+
+// WriteTo writes the document to w like `Display` and returns the number of bytes written.
+func (x SEmpty) WriteTo(w io.Writer) (int64, error) { return display(w, x) }
+
+// WriteTo writes the document to w like `Display` and returns the number of bytes written.
+func (x SChar) WriteTo(w io.Writer) (int64, error) { return display(w, x) }
+
+// WriteTo writes the document to w like `Display` and returns the number of bytes written.
+func (x SText) WriteTo(w io.Writer) (int64, error) { return display(w, x) }
+
+// WriteTo writes the document to w like `Display` and returns the number of bytes written.
+func (x SLine) WriteTo(w io.Writer) (int64, error) { return display(w, x) }
+
+// WriteTo writes the document to w like `Display` and returns the number of bytes written.
+func (x SAnnPush) WriteTo(w io.Writer) (int64, error) { return display(w, x) }
+
+// WriteTo writes the document to w like `Display` and returns the number of bytes written.
+func (x SAnnPop) WriteTo(w io.Writer) (int64, error) { return display(w, x) }
+
+// RenderedLine is a line of a rendered document.
+type RenderedLine struct {
+	// Indent is the number of columns the line is indented by. The first line is not indented.
+	Indent int
+
+	// Indentation is the indentation as `Display` writes it, with tabs if the document was rendered with `RenderOptions.IndentWithTabs`.
+	Indentation string
+
+	// Content is the text of the line after the indentation, including any trailing whitespace.
+	Content string
+}
+
+// String returns the line as `Display` writes it, without the line break.
+func (l RenderedLine) String() string {
+	return l.Indentation + l.Content
+}
+
+// RenderLines lays out the document according to the options and returns its lines.
+// The document has at least one line, which is empty for an empty document.
+func RenderLines(doc Doc, opts RenderOptions) []RenderedLine {
+	var lines []RenderedLine
+	Lines(Render(opts, doc))(func(l RenderedLine) bool {
+		lines = append(lines, l)
+		return true
+	})
+
+	return lines
+}
+
+// Lines returns an iterator over the lines of the SimpleDoc, in the same form as `Tokens`.
+// Annotations are ignored.
+func Lines(x SimpleDoc) func(yield func(RenderedLine) bool) {
+	return func(yield func(RenderedLine) bool) {
+		var (
+			current RenderedLine
+			content strings.Builder
+		)
+
+		for x := x; ; {
+			switch d := x.(type) {
+			case SEmpty:
+				current.Content = content.String()
+				yield(current)
+
+				return
+			case SChar:
+				content.WriteRune(d.char)
+				x = d.rest
+			case SText:
+				content.WriteString(d.text)
+				x = d.rest
+			case SLine:
+				current.Content = content.String()
+				if !yield(current) {
+					return
+				}
+
+				current = RenderedLine{Indent: d.indent, Indentation: d.Indentation()}
+				content.Reset()
+				x = d.rest
+			case SAnnPush:
+				x = d.rest
+			case SAnnPop:
+				x = d.rest
+			default:
+				panic(fmt.Sprintf("unexpected pprint.SimpleDoc: %#v", d))
+			}
+		}
+	}
+}
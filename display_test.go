@@ -0,0 +1,222 @@
+package pprint_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takoeight0821/pprint"
+)
+
+// plainWriter hides every method of the writer but Write.
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	return p.w.Write(b)
+}
+
+// failingWriter accepts n bytes and fails afterwards.
+type failingWriter struct {
+	n int
+}
+
+var errWrite = errors.New("write failed")
+
+func (f *failingWriter) Write(b []byte) (int, error) {
+	if len(b) > f.n {
+		n := f.n
+		f.n = 0
+
+		return n, errWrite
+	}
+	f.n -= len(b)
+
+	return len(b), nil
+}
+
+// countingWriter counts the calls to its methods, like a file that makes a system call for each.
+type countingWriter struct {
+	calls int
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	c.calls++
+
+	return len(b), nil
+}
+
+func (c *countingWriter) WriteString(s string) (int, error) {
+	c.calls++
+
+	return len(s), nil
+}
+
+func displayDoc() pprint.Doc {
+	return pprint.Vsep(
+		pprint.Nest(300, pprint.Vsep(pprint.Text("deep"), pprint.Hcat(pprint.Char('λ'), pprint.Char('x')))),
+		pprint.Nest(4, pprint.Vsep(pprint.Text("shallow"), pprint.Text("名前"))),
+	)
+}
+
+func TestRenderString(t *testing.T) {
+	t.Parallel()
+
+	want := "deep\n" + strings.Repeat(" ", 300) + "λx\nshallow\n    名前"
+	if diff := cmp.Diff(want, pprint.RenderString(displayDoc(), pprint.DefaultRenderOptions())); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+
+	tabs := pprint.RenderString(displayDoc(), pprint.RenderOptions{PageWidth: 80, IndentWithTabs: true, TabWidth: 4})
+	want = "deep\n" + strings.Repeat("\t", 75) + "λx\nshallow\n\t名前"
+	if diff := cmp.Diff(want, tabs); diff != "" {
+		t.Errorf("output mismatch with tabs (-want +got):\n%s", diff)
+	}
+}
+
+func TestNegativeNest(t *testing.T) {
+	t.Parallel()
+
+	doc := pprint.Nest(-3, pprint.Vsep(pprint.Text("a"), pprint.Text("b")))
+
+	for _, opts := range []pprint.RenderOptions{
+		pprint.DefaultRenderOptions(),
+		{PageWidth: 80, IndentWithTabs: true},
+	} {
+		if diff := cmp.Diff("a\nb", pprint.RenderString(doc, opts)); diff != "" {
+			t.Errorf("output mismatch (-want +got):\n%s", diff)
+		}
+
+		if got := pprint.RenderLines(doc, opts); got[1].String() != "b" {
+			t.Errorf("second line is %q, want %q", got[1].String(), "b")
+		}
+	}
+}
+
+func TestNegativeNestInTable(t *testing.T) {
+	t.Parallel()
+
+	doc := pprint.Table([][]pprint.Doc{{pprint.Nest(-3, pprint.Vsep(pprint.Text("a"), pprint.Text("b")))}}, pprint.TableOptions{})
+
+	if diff := cmp.Diff("a\nb", pprint.RenderString(doc, pprint.DefaultRenderOptions())); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	t.Parallel()
+
+	x := pprint.Render(pprint.DefaultRenderOptions(), displayDoc())
+	want := pprint.RenderString(displayDoc(), pprint.DefaultRenderOptions())
+
+	var got strings.Builder
+	n, err := x.WriteTo(plainWriter{&got})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(want, got.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+	if n != int64(len(want)) {
+		t.Errorf("WriteTo returned %d bytes, want %d", n, len(want))
+	}
+
+	if err := pprint.Display(&failingWriter{n: 10}, x); !errors.Is(err, errWrite) {
+		t.Errorf("Display to a failing writer returned %v, want %v", err, errWrite)
+	}
+}
+
+func TestDisplayAllocations(t *testing.T) {
+	x := pprint.Render(pprint.DefaultRenderOptions(), displayDoc())
+
+	var b bytes.Buffer
+	b.Grow(4096)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		b.Reset()
+		if err := pprint.Display(&b, x); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// The sink itself is the only allocation when writing to memory.
+	if allocs > 1 {
+		t.Errorf("Display to a bytes.Buffer allocated %v times per run, want at most 1", allocs)
+	}
+
+	lines := make([]pprint.Doc, 1000)
+	for i := range lines {
+		lines[i] = pprint.Nest(4, pprint.Vsep(pprint.Text("line"), pprint.Char('x')))
+	}
+	x = pprint.Render(pprint.DefaultRenderOptions(), pprint.Vsep(lines...))
+	size := len(pprint.RenderString(pprint.Vsep(lines...), pprint.DefaultRenderOptions()))
+
+	var w countingWriter
+	allocs = testing.AllocsPerRun(100, func() {
+		w.calls = 0
+		if err := pprint.Display(&w, x); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// The sink, the bufio.Writer and its buffer.
+	if allocs > 3 {
+		t.Errorf("Display to a writer allocated %v times per run, want at most 3", allocs)
+	}
+	if want := size/4096 + 1; w.calls > want {
+		t.Errorf("Display wrote %d times, want at most %d", w.calls, want)
+	}
+}
+
+func TestRenderLines(t *testing.T) {
+	t.Parallel()
+
+	doc := pprint.Vsep(
+		pprint.Text("func f() {"),
+		pprint.Nest(8, pprint.Vsep(pprint.Empty(), pprint.Hsep(pprint.Fill(6, pprint.Text("x")), pprint.Empty()))),
+		pprint.Annotate("end", pprint.Char('}')),
+	)
+
+	want := []pprint.RenderedLine{
+		{Content: "func f() {"},
+		{},
+		{Indent: 8, Indentation: "\t", Content: "x      "},
+		{Content: "}"},
+	}
+
+	got := pprint.RenderLines(doc, pprint.RenderOptions{PageWidth: 80, IndentWithTabs: true})
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("lines mismatch (-want +got):\n%s", diff)
+	}
+
+	var joined []string
+	for _, l := range got {
+		joined = append(joined, l.String())
+	}
+	display := pprint.RenderString(doc, pprint.RenderOptions{PageWidth: 80, IndentWithTabs: true})
+	if diff := cmp.Diff(display, strings.Join(joined, "\n")); diff != "" {
+		t.Errorf("lines differ from Display (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]pprint.RenderedLine{{}}, pprint.RenderLines(pprint.Empty(), pprint.DefaultRenderOptions())); diff != "" {
+		t.Errorf("lines of an empty document mismatch (-want +got):\n%s", diff)
+	}
+
+	lines := pprint.Lines(pprint.Render(pprint.DefaultRenderOptions(), doc))
+	for run := 0; run < 2; run++ {
+		var first []string
+		lines(func(l pprint.RenderedLine) bool {
+			first = append(first, l.Content)
+			return len(first) < 2
+		})
+
+		if diff := cmp.Diff([]string{"func f() {", ""}, first); diff != "" {
+			t.Errorf("run %d: first lines mismatch (-want +got):\n%s", run, diff)
+		}
+	}
+}
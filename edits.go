@@ -0,0 +1,400 @@
+package pprint
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextEdit replaces the part of a text between Start and End with NewText.
+// With `Position.Line` and `Position.UTF16Column` as the line and character, it is a Language Server Protocol TextEdit.
+type TextEdit struct {
+	Start   Position
+	End     Position
+	NewText string
+}
+
+// TextEdits lays out the document according to the options and returns the edits that turn the original text into the output.
+// The edits are ordered and do not overlap, and their positions are in the original text, so they can be applied together.
+//
+// The texts are compared line by line first. In every changed part, the lines are compared pairwise if their numbers
+// are equal, and together otherwise, as sequences of whitespace and non-whitespace runs. Where only whitespace differs,
+// the whitespace between the same characters is compared, so reformatting a text yields edits of whitespace
+// that leave the rest untouched. Every edit is narrowed to the bytes that differ.
+func TextEdits(original string, doc Doc, opts RenderOptions) []TextEdit {
+	return diffText(original, RenderString(doc, opts))
+}
+
+// maxEditDistance bounds the number of runs inserted and deleted that the comparison of a changed part searches for,
+// and so the time it takes. A part that differs in more runs is replaced as a whole.
+const maxEditDistance = 1000
+
+// differ collects the edits that turn a into another text.
+type differ struct {
+	a     string
+	lines []int
+	edits []TextEdit
+}
+
+// diffText returns the edits that turn a into b.
+func diffText(a, b string) []TextEdit {
+	d := differ{a: a, lines: lineStarts(a)}
+
+	la, lb := splitLines(a), splitLines(b)
+	offA, offB := tokenOffsets(la), tokenOffsets(lb)
+	hunks, _ := diffTokens(la, lb, len(la)+len(lb))
+
+	for _, h := range hunks {
+		if h.i1-h.i0 != h.j1-h.j0 {
+			d.text(offA[h.i0], offA[h.i1], b[offB[h.j0]:offB[h.j1]])
+			continue
+		}
+
+		for i, j := h.i0, h.j0; i < h.i1; i, j = i+1, j+1 {
+			d.text(offA[i], offA[i+1], lb[j])
+		}
+	}
+
+	return d.edits
+}
+
+// text adds the edits that turn a[start:end] into b.
+func (d *differ) text(start, end int, b string) {
+	if withoutSpace(d.a[start:end]) == withoutSpace(b) {
+		d.spaces(start, end, b)
+		return
+	}
+
+	ta, tb := tokenize(d.a[start:end]), tokenize(b)
+	hunks, ok := diffTokens(ta, tb, maxEditDistance)
+	if !ok {
+		d.add(start, end, b)
+		return
+	}
+
+	offA, offB := tokenOffsets(ta), tokenOffsets(tb)
+	for _, h := range hunks {
+		hStart, hEnd := start+offA[h.i0], start+offA[h.i1]
+		newText := b[offB[h.j0]:offB[h.j1]]
+
+		if withoutSpace(d.a[hStart:hEnd]) == withoutSpace(newText) {
+			d.spaces(hStart, hEnd, newText)
+		} else {
+			d.add(hStart, hEnd, newText)
+		}
+	}
+}
+
+// spaces adds the edits that turn a[start:end] into b, which has the same characters but whitespace.
+// The whitespace between every two characters is compared character by character.
+func (d *differ) spaces(start, end int, b string) {
+	a := d.a[start:end]
+
+	for i, j := 0, 0; ; i, j = i+1, j+1 {
+		i0, j0 := i, j
+		for i < len(a) && isSpace(a[i]) {
+			i++
+		}
+		for j < len(b) && isSpace(b[j]) {
+			j++
+		}
+
+		if a[i0:i] != b[j0:j] {
+			ra, rb := splitRunes(a[i0:i]), splitRunes(b[j0:j])
+			hunks, _ := diffTokens(ra, rb, len(ra)+len(rb))
+			for _, h := range hunks {
+				d.add(start+i0+h.i0, start+i0+h.i1, b[j0+h.j0:j0+h.j1])
+			}
+		}
+
+		if i == len(a) {
+			return
+		}
+	}
+}
+
+// add adds the edit replacing a[start:end] with newText, narrowed to the bytes that differ.
+func (d *differ) add(start, end int, newText string) {
+	prefix := commonPrefix(d.a[start:end], newText)
+	start += prefix
+	newText = newText[prefix:]
+
+	suffix := commonSuffix(d.a[start:end], newText)
+	end -= suffix
+	newText = newText[:len(newText)-suffix]
+
+	if start == end && newText == "" {
+		return
+	}
+
+	d.edits = append(d.edits, TextEdit{
+		Start:   position(d.a, d.lines, start),
+		End:     position(d.a, d.lines, end),
+		NewText: newText,
+	})
+}
+
+// tokenize splits the text into maximal runs of whitespace and of other characters.
+func tokenize(s string) []string {
+	var tokens []string
+
+	for start := 0; start < len(s); {
+		space := isSpace(s[start])
+		end := start + 1
+		for end < len(s) && isSpace(s[end]) == space {
+			end++
+		}
+
+		tokens = append(tokens, s[start:end])
+		start = end
+	}
+
+	return tokens
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// withoutSpace returns the text with its whitespace removed.
+func withoutSpace(s string) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if !isSpace(s[i]) {
+			b = append(b, s[i])
+		}
+	}
+
+	return string(b)
+}
+
+// splitRunes splits the text into its characters.
+func splitRunes(s string) []string {
+	runes := make([]string, 0, len(s))
+	for len(s) > 0 {
+		_, n := utf8.DecodeRuneInString(s)
+		runes = append(runes, s[:n])
+		s = s[n:]
+	}
+
+	return runes
+}
+
+// splitLines splits the text into its lines, each with its line break.
+func splitLines(s string) []string {
+	var lines []string
+	for len(s) > 0 {
+		n := strings.IndexByte(s, '\n') + 1
+		if n == 0 {
+			n = len(s)
+		}
+		lines = append(lines, s[:n])
+		s = s[n:]
+	}
+
+	return lines
+}
+
+// tokenOffsets returns the byte offset of every token and, last, of the end of the text.
+func tokenOffsets(tokens []string) []int {
+	offsets := make([]int, len(tokens)+1)
+	for i, t := range tokens {
+		offsets[i+1] = offsets[i] + len(t)
+	}
+
+	return offsets
+}
+
+// hunk is a part a[i0:i1] of a token sequence that is replaced by b[j0:j1].
+type hunk struct {
+	i0, i1 int
+	j0, j1 int
+}
+
+// diffTokens returns the parts of a that differ from b, using the linear space variant of Myers' O(ND) algorithm.
+// It reports false if more than maxD tokens would have to be inserted or deleted.
+func diffTokens(a, b []string, maxD int) ([]hunk, bool) {
+	m := myers{a: a, b: b}
+	if !m.compare(0, len(a), 0, len(b), maxD) {
+		return nil, false
+	}
+
+	var (
+		hunks []hunk
+		i, j  int
+	)
+
+	for _, mt := range append(m.matches, [2]int{len(a), len(b)}) {
+		if mt[0] > i || mt[1] > j {
+			hunks = append(hunks, hunk{i0: i, i1: mt[0], j0: j, j1: mt[1]})
+		}
+		i, j = mt[0]+1, mt[1]+1
+	}
+
+	return hunks, true
+}
+
+// myers finds the tokens that a and b keep in common.
+type myers struct {
+	a, b []string
+	// matches are the pairs of indices of the common tokens, in ascending order.
+	matches [][2]int
+	// forward and backward are the furthest reaching paths on every diagonal, from the start and from the end.
+	forward, backward []int
+}
+
+// compare adds the common tokens of a[a0:a1] and b[b0:b1] to the matches.
+// It reports false if more than maxD tokens would have to be inserted or deleted.
+func (m *myers) compare(a0, a1, b0, b1, maxD int) bool {
+	// Common prefixes and suffixes do not need the search.
+	for a0 < a1 && b0 < b1 && m.a[a0] == m.b[b0] {
+		m.matches = append(m.matches, [2]int{a0, b0})
+		a0++
+		b0++
+	}
+
+	suffix := 0
+	for a1 > a0 && b1 > b0 && m.a[a1-1] == m.b[b1-1] {
+		a1--
+		b1--
+		suffix++
+	}
+
+	if a0 < a1 && b0 < b1 {
+		x, y, u, v, ok := m.middleSnake(a0, a1, b0, b1, maxD)
+		if !ok {
+			return false
+		}
+
+		m.compare(a0, x, b0, y, x-a0+y-b0)
+		for ; x < u; x, y = x+1, y+1 {
+			m.matches = append(m.matches, [2]int{x, y})
+		}
+		m.compare(u, a1, v, b1, a1-u+b1-v)
+	} else if a1-a0+b1-b0 > maxD {
+		return false
+	}
+
+	for k := 0; k < suffix; k++ {
+		m.matches = append(m.matches, [2]int{a1 + k, b1 + k})
+	}
+
+	return true
+}
+
+// middleSnake returns the diagonal run from (x, y) to (u, v) in the middle of a shortest edit script
+// from a[a0:a1] to b[b0:b1], whose first tokens and last tokens differ. It searches from both ends at once,
+// so that it needs space only for the diagonals. It reports false if the script is longer than maxD.
+func (m *myers) middleSnake(a0, a1, b0, b1, maxD int) (x, y, u, v int, ok bool) {
+	n, mm := a1-a0, b1-b0
+	delta := n - mm
+	odd := delta%2 != 0
+
+	half := (n + mm + 1) / 2
+	offset := half + 1
+	if size := 2*offset + 1; len(m.forward) < size {
+		m.forward = make([]int, size)
+		m.backward = make([]int, size)
+	}
+	fw, bw := m.forward, m.backward
+	fw[offset+1], bw[offset+1] = 0, 0
+
+	for d := 0; d <= half && 2*d-1 <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var px int
+			if k == -d || (k != d && fw[offset+k-1] < fw[offset+k+1]) {
+				px = fw[offset+k+1]
+			} else {
+				px = fw[offset+k-1] + 1
+			}
+
+			py := px - k
+			ex, ey := px, py
+			for ex < n && ey < mm && m.a[a0+ex] == m.b[b0+ey] {
+				ex++
+				ey++
+			}
+			fw[offset+k] = ex
+
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && ex+bw[offset+c] >= n {
+				return a0 + px, b0 + py, a0 + ex, b0 + ey, 2*d-1 <= maxD
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var px int
+			if k == -d || (k != d && bw[offset+k-1] < bw[offset+k+1]) {
+				px = bw[offset+k+1]
+			} else {
+				px = bw[offset+k-1] + 1
+			}
+
+			py := px - k
+			ex, ey := px, py
+			for ex < n && ey < mm && m.a[a1-1-ex] == m.b[b1-1-ey] {
+				ex++
+				ey++
+			}
+			bw[offset+k] = ex
+
+			if c := delta - k; !odd && c >= -d && c <= d && ex+fw[offset+c] >= n {
+				return a1 - ex, b1 - ey, a1 - px, b1 - py, 2*d <= maxD
+			}
+		}
+	}
+
+	return 0, 0, 0, 0, false
+}
+
+// commonPrefix returns the length of the longest common prefix of a and b that ends at a character boundary.
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+
+	for n > 0 && n < len(a) && !utf8.RuneStart(a[n]) {
+		n--
+	}
+
+	return n
+}
+
+// commonSuffix returns the length of the longest common suffix of a and b that starts at a character boundary.
+func commonSuffix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+
+	for n > 0 && !utf8.RuneStart(a[len(a)-n]) {
+		n--
+	}
+
+	return n
+}
+
+// lineStarts returns the byte offset of the start of every line of the text.
+func lineStarts(s string) []int {
+	starts := []int{0}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+
+	return starts
+}
+
+// position returns the position of the byte offset in the text with the given line starts.
+func position(s string, lines []int, offset int) Position {
+	line := sort.SearchInts(lines, offset+1) - 1
+	start := lines[line]
+
+	return Position{
+		Offset:      offset,
+		Line:        line,
+		Column:      offset - start,
+		UTF16Column: utf16Len(s[start:offset]),
+	}
+}
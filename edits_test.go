@@ -0,0 +1,238 @@
+package pprint_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takoeight0821/pprint"
+)
+
+// applyEdits applies the edits, whose positions are in the original text, from the last to the first.
+func applyEdits(original string, edits []pprint.TextEdit) string {
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		original = original[:e.Start.Offset] + e.NewText + original[e.End.Offset:]
+	}
+
+	return original
+}
+
+func TestTextEdits(t *testing.T) {
+	t.Parallel()
+
+	call := pprint.Hcat(pprint.Text("print"), pprint.Tupled(pprint.Text(`"😀"`), pprint.Text("x"), pprint.Text("y")))
+	block := pprint.Vsep(
+		pprint.Nest(4, pprint.Vsep(pprint.Text("if x {"), call, pprint.Text("return"))),
+		pprint.Text("}"),
+	)
+
+	tests := []struct {
+		name     string
+		original string
+		doc      pprint.Doc
+		want     []pprint.TextEdit
+	}{
+		{
+			name:     "Unchanged",
+			original: "if x {\n    print(\"😀\",x,y)\n    return\n}",
+			doc:      block,
+			want:     nil,
+		},
+		{
+			name:     "Indentation",
+			original: "if x {\n  print(\"😀\",x,y)\n\treturn\n}",
+			doc:      block,
+			want: []pprint.TextEdit{
+				{
+					Start:   pprint.Position{Offset: 9, Line: 1, Column: 2, UTF16Column: 2},
+					End:     pprint.Position{Offset: 9, Line: 1, Column: 2, UTF16Column: 2},
+					NewText: "  ",
+				},
+				{
+					Start:   pprint.Position{Offset: 27, Line: 2},
+					End:     pprint.Position{Offset: 28, Line: 2, Column: 1, UTF16Column: 1},
+					NewText: "    ",
+				},
+			},
+		},
+		{
+			name:     "Spacing After Wide Characters",
+			original: "if x {\n    print(\"😀\" , x,y)\n    return\n}",
+			doc:      block,
+			want: []pprint.TextEdit{
+				{
+					Start: pprint.Position{Offset: 23, Line: 1, Column: 16, UTF16Column: 14},
+					End:   pprint.Position{Offset: 24, Line: 1, Column: 17, UTF16Column: 15},
+				},
+				{
+					Start: pprint.Position{Offset: 25, Line: 1, Column: 18, UTF16Column: 16},
+					End:   pprint.Position{Offset: 26, Line: 1, Column: 19, UTF16Column: 17},
+				},
+			},
+		},
+		{
+			name:     "Joined Lines",
+			original: "if x {\n    print(\"😀\",\n          x,\n          y)\n    return\n}",
+			doc:      block,
+			want: []pprint.TextEdit{
+				{
+					Start: pprint.Position{Offset: 24, Line: 1, Column: 17, UTF16Column: 15},
+					End:   pprint.Position{Offset: 35, Line: 2, Column: 10, UTF16Column: 10},
+				},
+				{
+					Start: pprint.Position{Offset: 37, Line: 2, Column: 12, UTF16Column: 12},
+					End:   pprint.Position{Offset: 48, Line: 3, Column: 10, UTF16Column: 10},
+				},
+			},
+		},
+		{
+			name:     "Space Between Tokens",
+			original: "func f(a,b) {",
+			doc:      pprint.Text("func f(a, b) {"),
+			want: []pprint.TextEdit{
+				{
+					Start:   pprint.Position{Offset: 9, Column: 9, UTF16Column: 9},
+					End:     pprint.Position{Offset: 9, Column: 9, UTF16Column: 9},
+					NewText: " ",
+				},
+			},
+		},
+		{
+			name:     "Empty Original",
+			original: "",
+			doc:      pprint.Text("x"),
+			want: []pprint.TextEdit{
+				{NewText: "x"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := pprint.DefaultRenderOptions()
+			edits := pprint.TextEdits(test.original, test.doc, opts)
+
+			if diff := cmp.Diff(test.want, edits); diff != "" {
+				t.Errorf("edits mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(pprint.RenderString(test.doc, opts), applyEdits(test.original, edits)); diff != "" {
+				t.Errorf("edited text mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTextEditsRewrite(t *testing.T) {
+	t.Parallel()
+
+	words := strings.Fields("the quick brown fox jumps over the lazy dog and keeps running far away")
+	docs := make([]pprint.Doc, len(words))
+	for i, w := range words {
+		docs[i] = pprint.Text(w)
+	}
+	doc := pprint.FillSep(docs...)
+
+	originals := []string{
+		strings.Join(words, "\n"),
+		"completely different text",
+		strings.Join(words[3:], "  ") + " tail",
+	}
+
+	for _, original := range originals {
+		opts := pprint.RenderOptions{PageWidth: 20}
+		edits := pprint.TextEdits(original, doc, opts)
+
+		if diff := cmp.Diff(pprint.RenderString(doc, opts), applyEdits(original, edits)); diff != "" {
+			t.Errorf("edited text of %q mismatch (-want +got):\n%s", original, diff)
+		}
+
+		for i := 1; i < len(edits); i++ {
+			if edits[i].Start.Offset < edits[i-1].End.Offset {
+				t.Errorf("edits %d and %d of %q overlap", i-1, i, original)
+			}
+		}
+	}
+}
+
+func TestTextEditsAllocations(t *testing.T) {
+	// A reindented file of a few thousand lines with one changed line.
+	var (
+		original []string
+		lines    []pprint.Doc
+	)
+	for i := 0; i < 3000; i++ {
+		line := fmt.Sprintf("x%d := f(a, b)", i)
+		original = append(original, "  "+line)
+		if i == 1500 {
+			line = "renamed := g(c)"
+		}
+		lines = append(lines, pprint.Text(line))
+	}
+	doc := pprint.Vsep(pprint.Text("{"), pprint.Indent(4, pprint.Vsep(lines...)), pprint.Text("}"))
+	text := "{\n" + strings.Join(original, "\n") + "\n}"
+	opts := pprint.DefaultRenderOptions()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	edits := pprint.TextEdits(text, doc, opts)
+	runtime.ReadMemStats(&after)
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64<<20 {
+		t.Errorf("TextEdits allocated %d bytes, want at most %d", allocated, 64<<20)
+	}
+
+	// Every line but the changed one gains two spaces and nothing else.
+	inserts := 0
+	for _, e := range edits {
+		if e.NewText == "  " && e.Start == e.End {
+			inserts++
+		}
+	}
+	if inserts != len(lines)-1 {
+		t.Errorf("got %d edits inserting two spaces, want %d", inserts, len(lines)-1)
+	}
+
+	if diff := cmp.Diff(pprint.RenderString(doc, opts), applyEdits(text, edits)); diff != "" {
+		t.Errorf("edited text mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTextEditsManyLines(t *testing.T) {
+	t.Parallel()
+
+	// Every line of the file needs a space, far more changes than a changed part is searched for.
+	var (
+		original []string
+		lines    []pprint.Doc
+	)
+	for i := 0; i < 1500; i++ {
+		original = append(original, fmt.Sprintf("x%d := f(a,b)", i))
+		lines = append(lines, pprint.Text(fmt.Sprintf("x%d := f(a, b)", i)))
+	}
+	doc := pprint.Vsep(lines...)
+	text := strings.Join(original, "\n")
+	opts := pprint.DefaultRenderOptions()
+
+	edits := pprint.TextEdits(text, doc, opts)
+	if len(edits) != len(lines) {
+		t.Errorf("got %d edits, want %d", len(edits), len(lines))
+	}
+
+	for _, e := range edits {
+		if e.Start != e.End || strings.TrimSpace(e.NewText) != "" {
+			t.Errorf("edit %+v is not an insertion of whitespace", e)
+			break
+		}
+	}
+
+	if diff := cmp.Diff(pprint.RenderString(doc, opts), applyEdits(text, edits)); diff != "" {
+		t.Errorf("edited text mismatch (-want +got):\n%s", diff)
+	}
+}
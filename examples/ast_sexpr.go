@@ -1,11 +1,7 @@
 // Example: Print S-expression of a simple AST
 package examples
 
-import (
-	"fmt"
-
-	"github.com/takoeight0821/pprint"
-)
+import "github.com/takoeight0821/pprint"
 
 type Expr struct {
 	Node interface {
@@ -33,7 +29,7 @@ type Number struct {
 func (Number) expr() {}
 
 func (n Number) Pretty() pprint.Doc {
-	return pprint.Text(fmt.Sprintf("%d", n.Value))
+	return pprint.Int(n.Value)
 }
 
 func NewNumber(value int) Expr {
@@ -47,11 +43,11 @@ type Add struct {
 func (Add) expr() {}
 
 func (a Add) Pretty() pprint.Doc {
-	return pprint.Sep(
-		pprint.Beside(pprint.Text("("), pprint.Text("+")),
+	return pprint.Parens(pprint.Sep(
+		pprint.Text("+"),
 		a.Left.Pretty(),
-		pprint.Beside(a.Right.Pretty(), pprint.Text(")")),
-	)
+		a.Right.Pretty(),
+	))
 }
 
 func NewAdd(left, right Expr) Expr {
@@ -2,7 +2,6 @@
 package examples
 
 import (
-	"fmt"
 	"reflect"
 
 	"github.com/takoeight0821/pprint"
@@ -18,7 +17,7 @@ func PrettyStruct(v any) pprint.Doc {
 	rv := reflect.ValueOf(v)
 	rt := reflect.TypeOf(v)
 	if rv.Kind() != reflect.Struct {
-		return pprint.Text(fmt.Sprintf("%v", v))
+		return pprint.Any(v)
 	}
 	var fields []pprint.Doc
 	for i := 0; i < rv.NumField(); i++ {
@@ -26,7 +25,7 @@ func PrettyStruct(v any) pprint.Doc {
 		fval := rv.Field(i).Interface()
 		fields = append(fields, pprint.Hsep(
 			pprint.Text(fname+":"),
-			pprint.Text(fmt.Sprintf("%v", fval)),
+			pprint.Any(fval),
 		))
 	}
 	return pprint.Vsep(fields...)
@@ -0,0 +1,186 @@
+package pprint
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// formatDoc implements `fmt.Formatter` for documents:
+//
+//   - `%v` and `%s` render the document with `DefaultRenderOptions`,
+//   - a width, as in `%100v`, renders it with that page width,
+//   - `%+v` renders it with `RenderCompact`,
+//   - `%#v` writes the structure of the document as the calls that build it.
+func formatDoc(f fmt.State, verb rune, doc Doc) {
+	switch verb {
+	case 'v', 's':
+	default:
+		fmt.Fprintf(f, "%%!%c(pprint.Doc)", verb)
+		return
+	}
+
+	switch {
+	case f.Flag('#'):
+		var b strings.Builder
+		dumpDoc(&b, doc)
+		_, _ = io.WriteString(f, b.String())
+	case f.Flag('+'):
+		_, _ = RenderCompact(doc).WriteTo(f)
+	default:
+		opts := DefaultRenderOptions()
+		if w, ok := f.Width(); ok {
+			opts.PageWidth = w
+		}
+
+		_, _ = Render(opts, doc).WriteTo(f)
+	}
+}
+
+// dumpDoc writes the document as the calls of the exported functions that build it.
+// A union is written as the `Group` of its shorter alternative, since `Group` is the only way to build one.
+func dumpDoc(b *strings.Builder, doc Doc) {
+	call := func(name string, args ...Doc) {
+		b.WriteString("pprint.")
+		b.WriteString(name)
+		b.WriteByte('(')
+		for i, arg := range args {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			dumpDoc(b, arg)
+		}
+		b.WriteByte(')')
+	}
+
+	switch d := doc.(type) {
+	case empty:
+		call("Empty")
+	case char:
+		fmt.Fprintf(b, "pprint.Char(%q)", rune(d))
+	case text:
+		fmt.Fprintf(b, "pprint.Text(%q)", string(d))
+	case line:
+		if d.Literal {
+			call("LiteralLine")
+		} else {
+			call("HardLine")
+		}
+	case flatAlt:
+		call("FlatAlt", d.Broken, d.Flat)
+	case cat:
+		call("Hcat", catParts(d)...)
+	case nest:
+		fmt.Fprintf(b, "pprint.Nest(%d, ", d.Indent)
+		dumpDoc(b, d.Doc)
+		b.WriteByte(')')
+	case union:
+		call("Group", d.Shorter)
+	case flattened:
+		b.WriteString("flatten(")
+		dumpDoc(b, d.Doc)
+		b.WriteByte(')')
+	case annotate:
+		fmt.Fprintf(b, "pprint.Annotate(%#v, ", d.Ann)
+		dumpDoc(b, d.Doc)
+		b.WriteByte(')')
+	case annotationEnd:
+		b.WriteString("annotationEnd")
+	case column:
+		b.WriteString("pprint.Column(func)")
+	case nesting:
+		b.WriteString("pprint.Nesting(func)")
+	case withOptions:
+		b.WriteString("pprint.WithOptions(func)")
+	case joinPoint:
+		b.WriteString("joinPoint")
+	default:
+		fmt.Fprintf(b, "%T", d)
+	}
+}
+
+// catParts returns the documents concatenated by nested cats, from left to right.
+func catParts(c cat) []Doc {
+	var (
+		parts []Doc
+		stack = []Doc{c}
+	)
+
+	for len(stack) > 0 {
+		d := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if c, ok := d.(cat); ok {
+			stack = append(stack, c.Second, c.First)
+		} else {
+			parts = append(parts, d)
+		}
+	}
+
+	return parts
+}
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d empty) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d char) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d text) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d line) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d flatAlt) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d cat) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d nest) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d union) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d flattened) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d annotate) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d annotationEnd) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d column) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d nesting) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d withOptions) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Format implements `fmt.Formatter`; see `Formatter`.
+func (d joinPoint) Format(f fmt.State, verb rune) { formatDoc(f, verb, d) }
+
+// Formatter returns a value that formats with the `fmt` verbs like the document of the value:
+//
+//	fmt.Printf("%v", Formatter(x))    // x.Pretty() rendered at 80 columns
+//	fmt.Printf("%100v", Formatter(x)) // rendered at 100 columns
+//	fmt.Printf("%+v", Formatter(x))   // rendered compactly
+//	fmt.Printf("%#v", Formatter(x))   // the structure of the document
+//
+// Every `Doc` formats this way on its own.
+func Formatter(x Pretty) fmt.Formatter {
+	return prettyFormatter{x}
+}
+
+type prettyFormatter struct {
+	x Pretty
+}
+
+func (p prettyFormatter) Format(f fmt.State, verb rune) {
+	formatDoc(f, verb, p.x.Pretty())
+}
@@ -0,0 +1,99 @@
+package pprint_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takoeight0821/pprint"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	words := make([]pprint.Doc, 20)
+	for i := range words {
+		words[i] = pprint.Text("word")
+	}
+	sentence := pprint.FillSep(words...)
+
+	tests := []struct {
+		name   string
+		format string
+		arg    any
+		want   string
+	}{
+		{
+			name:   "Default Width",
+			format: "%v",
+			arg:    sentence,
+			want:   strings.Repeat("word ", 15) + "word\n" + strings.TrimSuffix(strings.Repeat("word ", 4), " "),
+		},
+		{
+			name:   "Verb Width",
+			format: "%20v",
+			arg:    sentence,
+			want:   strings.TrimSuffix(strings.Repeat("word word word word\n", 5), "\n"),
+		},
+		{
+			name:   "String Verb",
+			format: "<%s>",
+			arg:    pprint.Sep(pprint.Text("a"), pprint.Text("b")),
+			want:   "<a b>",
+		},
+		{
+			name:   "Compact",
+			format: "%+v",
+			arg:    pprint.Group(pprint.Nest(4, pprint.Vsep(pprint.Text("a"), pprint.Text("b")))),
+			want:   "a\nb",
+		},
+		{
+			name:   "Structure",
+			format: "%#v",
+			arg: pprint.Group(pprint.Nest(2, pprint.Hcat(
+				pprint.Char('('),
+				pprint.Annotate("kw", pprint.Text("if")),
+				pprint.LineBreak(),
+				pprint.Char(')'),
+			))),
+			want: `pprint.Group(pprint.Nest(2, pprint.Hcat(pprint.Char('('), pprint.Annotate("kw", pprint.Text("if")), ` +
+				`pprint.FlatAlt(pprint.HardLine(), pprint.Empty()), pprint.Char(')'))))`,
+		},
+		{
+			name:   "Functions",
+			format: "%#v",
+			arg:    pprint.Align(pprint.Text("x")),
+			want:   `pprint.Column(func)`,
+		},
+		{
+			name:   "Bad Verb",
+			format: "%d",
+			arg:    pprint.Text("x"),
+			want:   "%!d(pprint.Doc)",
+		},
+		{
+			name:   "Pretty",
+			format: "%v",
+			arg:    pprint.Formatter(point{1, 2}),
+			want:   "(1,2)",
+		},
+		{
+			name:   "Pretty Structure",
+			format: "%#v",
+			arg:    pprint.Formatter(point{1, 2}),
+			want:   `pprint.Column(func)`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(test.want, fmt.Sprintf(test.format, test.arg)); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package pprint
+
+import (
+	"fmt"
+	"html"
+	"io"
+)
+
+// HTMLOptions configures `DisplayHTML`.
+type HTMLOptions struct {
+	// Tag returns the markup written before and after a document annotated with ann.
+	// Returning two empty strings leaves the annotation out of the output.
+	// If Tag is nil, every annotation but a `Marker` becomes a `<span>` whose class is the annotation formatted with `fmt.Sprint`.
+	Tag func(ann any) (open, close string)
+
+	// PreClass is the class of the enclosing `<pre>` element. The attribute is omitted if it is empty.
+	PreClass string
+}
+
+// spanTag wraps a document in a span with the annotation as its class. `Marker` annotations are left out.
+func spanTag(ann any) (string, string) {
+	if _, ok := ann.(Marker); ok {
+		return "", ""
+	}
+
+	return `<span class="` + html.EscapeString(fmt.Sprint(ann)) + `">`, "</span>"
+}
+
+// DisplayHTML writes the rendered SimpleDoc to the given writer as a `<pre>` element.
+// Text is HTML-escaped, indentation is kept as spaces, and annotated documents are wrapped in the markup chosen by `HTMLOptions.Tag`.
+func DisplayHTML(w io.Writer, x SimpleDoc, opts HTMLOptions) error {
+	tag := opts.Tag
+	if tag == nil {
+		tag = spanTag
+	}
+
+	pre := "<pre>"
+	if opts.PreClass != "" {
+		pre = `<pre class="` + html.EscapeString(opts.PreClass) + `">`
+	}
+
+	out := newSink(w)
+	out.writeString(pre)
+
+	var ends []string
+
+	for out.err == nil {
+		switch d := x.(type) {
+		case SEmpty:
+			out.writeString("</pre>")
+			_, err := out.flush()
+
+			return err
+		case SChar:
+			switch d.char {
+			case '<', '>', '&', '\'', '"':
+				out.writeString(html.EscapeString(string(d.char)))
+			default:
+				out.writeRune(d.char)
+			}
+			x = d.rest
+		case SText:
+			out.writeString(html.EscapeString(d.text))
+			x = d.rest
+		case SLine:
+			out.writeLine(d)
+			x = d.rest
+		case SAnnPush:
+			start, end := tag(d.ann)
+			ends = append(ends, end)
+			out.writeString(start)
+			x = d.rest
+		case SAnnPop:
+			if len(ends) > 0 {
+				out.writeString(ends[len(ends)-1])
+				ends = ends[:len(ends)-1]
+			}
+			x = d.rest
+		default:
+			panic(fmt.Sprintf("unexpected pprint.SimpleDoc: %#v", d))
+		}
+	}
+
+	return out.err
+}
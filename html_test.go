@@ -0,0 +1,90 @@
+package pprint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takoeight0821/pprint"
+)
+
+func TestDisplayHTML(t *testing.T) {
+	t.Parallel()
+
+	doc := pprint.Vsep(
+		pprint.Hsep(pprint.Annotate("kw", pprint.Text("if")), pprint.Text("a < b && c > d"), pprint.Char('{')),
+		pprint.Indent(2, pprint.Annotate("call", pprint.Hcat(
+			pprint.Text("print"),
+			pprint.Char('('),
+			pprint.Annotate("str", pprint.Text(`"<'&'>"`)),
+			pprint.Char(')'),
+		))),
+		pprint.Char('}'),
+	)
+
+	tests := []struct {
+		name string
+		opts pprint.HTMLOptions
+		want string
+	}{
+		{
+			name: "Default Spans",
+			want: "<pre>" +
+				`<span class="kw">if</span> a &lt; b &amp;&amp; c &gt; d {` + "\n" +
+				`  <span class="call">print(<span class="str">&#34;&lt;&#39;&amp;&#39;&gt;&#34;</span>)</span>` + "\n" +
+				"}</pre>",
+		},
+		{
+			name: "Custom Tags",
+			opts: pprint.HTMLOptions{
+				PreClass: "code listing",
+				Tag: func(ann any) (string, string) {
+					switch ann {
+					case "kw":
+						return "<b>", "</b>"
+					case "str":
+						return "<i>", "</i>"
+					default:
+						return "", ""
+					}
+				},
+			},
+			want: `<pre class="code listing">` +
+				`<b>if</b> a &lt; b &amp;&amp; c &gt; d {` + "\n" +
+				`  print(<i>&#34;&lt;&#39;&amp;&#39;&gt;&#34;</i>)` + "\n" +
+				"}</pre>",
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.DisplayHTML(&got, pprint.RenderPretty(1, 80, doc), test.opts); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, got.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDisplayHTMLMarker(t *testing.T) {
+	t.Parallel()
+
+	doc := pprint.Hsep(pprint.Annotate("kw", pprint.Text("return")), pprint.Mark(1, pprint.Annotate("num", pprint.Text("42"))))
+
+	var got strings.Builder
+	if err := pprint.DisplayHTML(&got, pprint.RenderPretty(1, 80, doc), pprint.HTMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<pre><span class="kw">return</span> <span class="num">42</span></pre>`
+	if diff := cmp.Diff(want, got.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,309 @@
+package pprint
+
+import (
+	"fmt"
+	"sort"
+)
+
+// maxFrontier is the maximal number of partial layouts the optimal layout mode keeps for one position in the document.
+const maxFrontier = 64
+
+// layoutCost is the cost of a layout: the number of hard line breaks in flattened documents,
+// then the number of columns past the page or ribbon width, then the number of lines.
+type layoutCost struct {
+	flatBreaks int
+	overflow   int
+	lines      int
+}
+
+func (c layoutCost) less(d layoutCost) bool {
+	if c.flatBreaks != d.flatBreaks {
+		return c.flatBreaks < d.flatBreaks
+	}
+
+	if c.overflow != d.overflow {
+		return c.overflow < d.overflow
+	}
+
+	return c.lines < d.lines
+}
+
+// output is a persistent list of rendered nodes, newest first, so partial layouts can share their common prefix.
+type output struct {
+	node SimpleDoc
+	prev *output
+}
+
+// partial is a layout of a prefix of the document.
+type partial struct {
+	n    int
+	k    int
+	cost layoutCost
+	out  *output
+	// group is the join of the group whose flat alternative the layout is in, or nil.
+	group *join
+}
+
+// bundle is a set of partial layouts that continue with the same rest of the document.
+type bundle struct {
+	docs   *Docs
+	states []partial
+}
+
+// join collects the partial layouts of the alternatives of a union or column until all of them are laid out.
+type join struct {
+	pending int
+	states  []partial
+}
+
+// joinPoint marks the end of the alternatives of a join on the work list of `optimal`.
+type joinPoint struct {
+	join *join
+}
+
+func (joinPoint) doc() {}
+
+var _ Doc = joinPoint{}
+
+// optimal lays out docs with the least cost, in the spirit of Bernardy's "A pretty but not greedy printer".
+//
+// All partial layouts at the same position in the document are processed together. At a union, both alternatives
+// are laid out for every partial layout, and their results are merged once the union is done; a column document
+// forks the same way if the partial layouts are at different columns. The future of a partial layout depends only on
+// its column and indentation, so merging keeps, for each indentation, only the layouts that are cheaper than every layout
+// ending at a smaller or equal column. After a line break, all layouts are at the same column and only the cheapest one survives.
+//
+// To bound the search, at most `maxFrontier` partial layouts are kept, preferring the cheapest, and like `fits`,
+// the flat alternative of a group is given up as soon as it overflows the page or the ribbon or breaks a line,
+// so that a flattened document is not walked to its end again for every group it is nested in.
+// The result is optimal among the layouts whose flat groups fit as long as the bound is not hit and the cost of
+// the rest of the document does not decrease as its start column grows, which holds for all documents not built with `Column`.
+func (p pretty) optimal(docs *Docs) SimpleDoc {
+	var (
+		current = bundle{docs: docs, states: []partial{{}}}
+		waiting []bundle
+	)
+
+	for current.docs != Nil() {
+		if len(current.states) == 0 {
+			// Every layout of a flat alternative was given up: skip to the end of the alternative.
+			for {
+				if _, ok := current.docs.Doc.(joinPoint); ok {
+					break
+				}
+				current.docs = current.docs.Rest
+			}
+		}
+
+		i := current.docs.Indent
+		d := current.docs.Doc
+		flat := current.docs.flat
+		current.docs = current.docs.Rest
+
+		switch d := d.(type) {
+		case empty:
+		case char:
+			current.states = p.advance(current.states, string(d), SChar{char: rune(d)})
+		case text:
+			current.states = p.advance(current.states, string(d), nil)
+		case line:
+			indent := d.indentation(i)
+			kept := current.states[:0]
+			for _, s := range current.states {
+				if flat && s.group != nil {
+					continue
+				}
+
+				s.out = &output{node: p.newline(indent), prev: s.out}
+				s.n, s.k = indent, indent
+				s.cost.lines++
+				if flat {
+					s.cost.flatBreaks++
+				}
+				kept = append(kept, s)
+			}
+			current.states = prune(kept)
+		case flatAlt:
+			if flat {
+				current.docs = consFlat(i, flat, d.Flat, current.docs)
+			} else {
+				current.docs = consFlat(i, flat, d.Broken, current.docs)
+			}
+		case cat:
+			current.docs = consFlat(i, flat, d.First, consFlat(i, flat, d.Second, current.docs))
+		case nest:
+			current.docs = consFlat(i+d.Indent, flat, d.Doc, current.docs)
+		case flattened:
+			current.docs = consFlat(i, true, d.Doc, current.docs)
+		case annotate:
+			for j := range current.states {
+				s := &current.states[j]
+				s.out = &output{node: SAnnPush{ann: d.Ann}, prev: s.out}
+			}
+			current.docs = consFlat(i, flat, d.Doc, consFlat(i, flat, annotationEnd{}, current.docs))
+		case annotationEnd:
+			for j := range current.states {
+				s := &current.states[j]
+				s.out = &output{node: SAnnPop{}, prev: s.out}
+			}
+		case union:
+			if flat {
+				current.docs = consFlat(i, flat, d.Longer, current.docs)
+			} else {
+				j := &join{pending: 2}
+				end := consFlat(i, flat, joinPoint{join: j}, current.docs)
+				waiting = append(waiting, bundle{
+					docs:   consFlat(i, flat, d.Shorter, end),
+					states: append([]partial(nil), current.states...),
+				})
+				for k := range current.states {
+					if current.states[k].group == nil {
+						current.states[k].group = j
+					}
+				}
+				current.docs = consFlat(i, flat, d.Longer, end)
+			}
+		case column:
+			if byColumn := groupByColumn(current.states); len(byColumn) == 1 {
+				current.docs = consFlat(i, flat, d(current.states[0].k), current.docs)
+			} else {
+				end := consFlat(i, flat, joinPoint{join: &join{pending: len(byColumn)}}, current.docs)
+				for j := len(byColumn) - 1; j >= 0; j-- {
+					waiting = append(waiting, bundle{
+						docs:   consFlat(i, flat, d(byColumn[j][0].k), end),
+						states: byColumn[j],
+					})
+				}
+				current = waiting[len(waiting)-1]
+				waiting = waiting[:len(waiting)-1]
+			}
+		case nesting:
+			current.docs = consFlat(i, flat, d(i), current.docs)
+		case withOptions:
+			current.docs = consFlat(i, flat, d(p.opts), current.docs)
+		case joinPoint:
+			for k := range current.states {
+				if current.states[k].group == d.join {
+					current.states[k].group = nil
+				}
+			}
+			d.join.states = append(d.join.states, current.states...)
+			d.join.pending--
+
+			if d.join.pending > 0 {
+				current = waiting[len(waiting)-1]
+				waiting = waiting[:len(waiting)-1]
+			} else {
+				current.states = prune(d.join.states)
+			}
+		default:
+			panic(fmt.Sprintf("unexpected pprint.Doc: %#v", d))
+		}
+	}
+
+	cheapest := current.states[0]
+	for _, s := range current.states[1:] {
+		if s.cost.less(cheapest.cost) {
+			cheapest = s
+		}
+	}
+
+	var nodes []SimpleDoc
+	for out := cheapest.out; out != nil; out = out.prev {
+		nodes = append(nodes, out.node)
+	}
+
+	var b simpleDocBuilder
+	for j := len(nodes) - 1; j >= 0; j-- {
+		b.add(nodes[j])
+	}
+
+	return b.build()
+}
+
+// advance adds a text to every partial layout, charging the columns that overflow the page or the ribbon,
+// and returns the layouts that are kept: those in the flat alternative of a group are given up when they overflow.
+// If node is nil, the text is written as the node `emit` makes for the column of each layout.
+func (p pretty) advance(states []partial, s string, node SimpleDoc) []partial {
+	if s == "\t" && p.expand {
+		node = nil
+	}
+
+	kept := states[:0]
+	for _, st := range states {
+		l := p.after(st.k, s) - st.k
+
+		if !p.unbounded {
+			limit := min(p.w, st.n+p.r)
+			overflow := max(0, st.k+l-max(st.k, limit))
+			if overflow > 0 && st.group != nil {
+				continue
+			}
+			st.cost.overflow += overflow
+		}
+
+		if node == nil {
+			st.out = &output{node: p.emit(st.k, s), prev: st.out}
+		} else {
+			st.out = &output{node: node, prev: st.out}
+		}
+
+		st.k += l
+		kept = append(kept, st)
+	}
+
+	return kept
+}
+
+// groupByColumn splits the partial layouts into groups at the same column, in order of first appearance.
+func groupByColumn(states []partial) [][]partial {
+	var groups [][]partial
+
+	for _, s := range states {
+		found := false
+		for j, g := range groups {
+			if g[0].k == s.k {
+				groups[j] = append(g, s)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			groups = append(groups, []partial{s})
+		}
+	}
+
+	return groups
+}
+
+// prune drops the partial layouts that are dominated by another layout with the same indentation
+// that ends at a smaller or equal column and costs no more, and keeps at most `maxFrontier` of the rest.
+func prune(states []partial) []partial {
+	sort.SliceStable(states, func(a, b int) bool {
+		if states[a].n != states[b].n {
+			return states[a].n < states[b].n
+		}
+		if states[a].k != states[b].k {
+			return states[a].k < states[b].k
+		}
+
+		return states[a].cost.less(states[b].cost)
+	})
+
+	kept := states[:0]
+	for _, s := range states {
+		if len(kept) == 0 || kept[len(kept)-1].n != s.n || s.cost.less(kept[len(kept)-1].cost) {
+			kept = append(kept, s)
+		}
+	}
+
+	if len(kept) > maxFrontier {
+		sort.SliceStable(kept, func(a, b int) bool {
+			return kept[a].cost.less(kept[b].cost)
+		})
+		kept = kept[:maxFrontier]
+	}
+
+	return kept
+}
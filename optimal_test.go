@@ -0,0 +1,127 @@
+package pprint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takoeight0821/pprint"
+)
+
+func TestLayoutOptimal(t *testing.T) {
+	t.Parallel()
+
+	fun := func(x pprint.Doc) pprint.Doc {
+		return pprint.Beside(pprint.Hang(2, pprint.Hcat(pprint.Text("fun("), pprint.SoftBreak(), x)), pprint.Char(')'))
+	}
+	list := pprint.Align(pprint.Hcat(
+		pprint.Text("[ "),
+		pprint.Cat(pprint.Text("abcdef"), pprint.Beside(pprint.Text(", "), pprint.Text("ghijklm"))),
+		pprint.Text(" ]"),
+	))
+
+	words := make([]pprint.Doc, 300)
+	for i := range words {
+		words[i] = pprint.Text(strings.Repeat("w", i%7+1))
+	}
+
+	tests := []struct {
+		name  string
+		width int
+		doc   pprint.Doc
+		want  []string
+	}{
+		{
+			name:  "Hanging Calls",
+			width: 26,
+			doc:   fun(fun(fun(fun(fun(list))))),
+			want: []string{
+				"fun(",
+				"  fun(",
+				"    fun(",
+				"      fun(",
+				"        fun(",
+				"          [ abcdef",
+				"          , ghijklm ])))))",
+			},
+		},
+		{
+			name:  "Aligned Arguments",
+			width: 12,
+			doc: pprint.Hcat(
+				pprint.Text("call("),
+				pprint.SoftBreak(),
+				pprint.Align(pprint.Vcat(pprint.Text("aaaa,"), pprint.Text("bbbbbbbbbb"))),
+				pprint.Char(')'),
+			),
+			want: []string{
+				"call(",
+				"aaaa,",
+				"bbbbbbbbbb)",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.Display(&got, pprint.Render(pprint.RenderOptions{PageWidth: test.width, Layout: pprint.LayoutOptimal}, test.doc)); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, strings.Split(got.String(), "\n")); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("Fill", func(t *testing.T) {
+		t.Parallel()
+
+		var wadler, optimal strings.Builder
+		if err := pprint.Display(&wadler, pprint.Render(pprint.RenderOptions{PageWidth: 40}, pprint.FillSep(words...))); err != nil {
+			t.Fatal(err)
+		}
+		if err := pprint.Display(&optimal, pprint.Render(pprint.RenderOptions{PageWidth: 40, Layout: pprint.LayoutOptimal}, pprint.FillSep(words...))); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, l := range strings.Split(optimal.String(), "\n") {
+			if len(l) > 40 {
+				t.Errorf("line overflows the page: %q", l)
+			}
+		}
+
+		if got, want := strings.Count(optimal.String(), "\n"), strings.Count(wadler.String(), "\n"); got > want {
+			t.Errorf("optimal layout has %d line breaks, greedy layout has %d", got, want)
+		}
+	})
+}
+
+func TestLayoutOptimalDepth(t *testing.T) {
+	nested := func(depth int) pprint.Doc {
+		doc := pprint.Text("x")
+		for i := 0; i < depth; i++ {
+			doc = pprint.Hcat(pprint.Text("f"), pprint.Tupled(doc, pprint.Text("y")))
+		}
+
+		return doc
+	}
+
+	opts := pprint.RenderOptions{PageWidth: 80, Layout: pprint.LayoutOptimal}
+	allocs := func(depth int) float64 {
+		doc := nested(depth)
+
+		return testing.AllocsPerRun(3, func() {
+			pprint.Render(opts, doc)
+		})
+	}
+
+	// The work grows with the depth, not with its square, because flat alternatives that overflow are given up.
+	if small, large := allocs(400), allocs(1600); large > 6*small {
+		t.Errorf("rendering at depth 1600 allocated %v times, %.1f times as much as at depth 400", large, large/small)
+	}
+}
@@ -178,15 +178,230 @@ func Align(doc Doc) Doc {
 	})
 }
 
+// Enclose encloses the document between left and right.
+func Enclose(left, right, doc Doc) Doc {
+	return Hcat(left, doc, right)
+}
+
+// Parens encloses the document in parentheses.
+func Parens(doc Doc) Doc {
+	return Enclose(Char('('), Char(')'), doc)
+}
+
+// Brackets encloses the document in square brackets.
+func Brackets(doc Doc) Doc {
+	return Enclose(Char('['), Char(']'), doc)
+}
+
+// Braces encloses the document in braces.
+func Braces(doc Doc) Doc {
+	return Enclose(Char('{'), Char('}'), doc)
+}
+
+// Angles encloses the document in angle brackets.
+func Angles(doc Doc) Doc {
+	return Enclose(Char('<'), Char('>'), doc)
+}
+
+// Squotes encloses the document in single quotes.
+func Squotes(doc Doc) Doc {
+	return Enclose(Char('\''), Char('\''), doc)
+}
+
+// Dquotes encloses the document in double quotes.
+func Dquotes(doc Doc) Doc {
+	return Enclose(Char('"'), Char('"'), doc)
+}
+
+// EncloseSep concatenates the documents separated by sep and encloses the result between left and right.
+// The documents are laid out horizontally if that fits the page, and otherwise vertically with the separators
+// leading each line, aligned to the current column.
+//
+//	EncloseSep(Char('['), Char(']'), Char(','), Text("10"), Text("200"), Text("3000"))
+//
+// will render as `[10,200,3000]`, or if it does not fit the page as:
+//
+//	[10
+//	,200
+//	,3000]
+func EncloseSep(left, right, sep Doc, docs ...Doc) Doc {
+	switch len(docs) {
+	case 0:
+		return Beside(left, right)
+	case 1:
+		return Hcat(left, docs[0], right)
+	default:
+		elems := make([]Doc, len(docs))
+		elems[0] = Beside(left, docs[0])
+		for i, d := range docs[1:] {
+			elems[i+1] = Beside(sep, d)
+		}
+
+		return Align(Beside(Cat(elems...), right))
+	}
+}
+
+// List encloses the documents in square brackets, separated by commas, like `EncloseSep`.
+func List(docs ...Doc) Doc {
+	return EncloseSep(Char('['), Char(']'), Char(','), docs...)
+}
+
+// Tupled encloses the documents in parentheses, separated by commas, like `EncloseSep`.
+func Tupled(docs ...Doc) Doc {
+	return EncloseSep(Char('('), Char(')'), Char(','), docs...)
+}
+
+// SemiBraces encloses the documents in braces, separated by semicolons, like `EncloseSep`.
+func SemiBraces(docs ...Doc) Doc {
+	return EncloseSep(Char('{'), Char('}'), Char(';'), docs...)
+}
+
+// Justify concatenates the documents like `FillSep`, but widens the spaces between the documents on every line except the last,
+// so that the line reaches the page or ribbon width. Like `FillSep`, the lines after the first start at the current indentation level.
+// The documents are measured as if they were laid out flat, and are usually single words.
+func Justify(words ...Doc) Doc {
+	if len(words) == 0 {
+		return Empty()
+	}
+
+	return WithOptions(func(opts RenderOptions) Doc {
+		if opts.PageWidth < 0 {
+			return Hsep(words...)
+		}
+
+		widths := make([]int, len(words))
+		for i, w := range words {
+			widths[i] = opts.flatWidth(w)
+		}
+
+		return Column(func(k int) Doc {
+			return Nesting(func(i int) Doc {
+				return justify(words, widths, k, i, min(opts.PageWidth, i+opts.ribbonWidth()))
+			})
+		})
+	})
+}
+
+// justify breaks the words into lines that end before limit, starting at column k and then at column i, and spreads each line but the last to the limit.
+func justify(words []Doc, widths []int, k, i, limit int) Doc {
+	var lines []Doc
+
+	column := k
+	for start := 0; start < len(words); {
+		end, used := start+1, widths[start]
+		for end < len(words) && column+used+1+widths[end] <= limit {
+			used += 1 + widths[end]
+			end++
+		}
+
+		if end == len(words) {
+			lines = append(lines, Hsep(words[start:end]...))
+		} else {
+			lines = append(lines, spread(words[start:end], limit-column-used))
+		}
+
+		start, column = end, i
+	}
+
+	return mergeDocs(lines, func(a, b Doc) Doc {
+		return Beside(a, Beside(line{}, b))
+	})
+}
+
+// spread separates the words by single spaces, plus extra spaces distributed over the gaps from the left.
+func spread(words []Doc, extra int) Doc {
+	gaps := len(words) - 1
+	if gaps == 0 {
+		return words[0]
+	}
+
+	doc := words[0]
+	for j, w := range words[1:] {
+		n := 1 + extra/gaps
+		if j < extra%gaps {
+			n++
+		}
+
+		doc = Hcat(doc, Text(Spaces(n)), w)
+	}
+
+	return doc
+}
+
+// Words splits the string on white space into a list of `Text` documents.
+func Words(s string) []Doc {
+	fields := strings.Fields(s)
+	docs := make([]Doc, len(fields))
+	for i, f := range fields {
+		docs[i] = Text(f)
+	}
+
+	return docs
+}
+
+// Reflow fills the paragraphs of the string to the page width like `FillSep`.
+// Paragraphs are separated by blank lines, which are kept as a single empty line.
+// Other line breaks and white space in a paragraph are treated as word separators.
+func Reflow(s string) Doc {
+	return reflow(s, func(lines []string) Doc {
+		return FillSep(Words(strings.Join(lines, " "))...)
+	})
+}
+
+// ReflowLines is like `Reflow`, but keeps every line break of the string and only breaks lines that do not fit the page.
+func ReflowLines(s string) Doc {
+	return reflow(s, func(lines []string) Doc {
+		docs := make([]Doc, len(lines))
+		for i, l := range lines {
+			docs[i] = FillSep(Words(l)...)
+		}
+
+		return mergeDocs(docs, func(a, b Doc) Doc {
+			return Beside(a, Beside(line{}, b))
+		})
+	})
+}
+
+// reflow splits the string into paragraphs at blank lines, fills each paragraph with fill, and separates them with an empty line.
+func reflow(s string, fill func(lines []string) Doc) Doc {
+	var (
+		paragraphs []Doc
+		lines      []string
+	)
+
+	for _, l := range append(strings.Split(s, "\n"), "") {
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+			continue
+		}
+
+		if len(lines) > 0 {
+			paragraphs = append(paragraphs, fill(lines))
+			lines = nil
+		}
+	}
+
+	return mergeDocs(paragraphs, func(a, b Doc) Doc {
+		return Hcat(a, line{Literal: true}, line{}, b)
+	})
+}
+
 // Spaces returns a string consisting of n space characters.
 func Spaces(n int) string {
+	if n >= 0 && n < len(newlineSpaces) {
+		return newlineSpaces[1 : 1+n]
+	}
+
 	return strings.Repeat(" ", n)
 }
 
 // Primitives
 
 // Doc represents a pretty-printable document. Implementations of Doc are used to build and render formatted output.
+//
+// Documents implement `fmt.Formatter`, so they can be printed with `fmt.Printf` and friends; see `Formatter` for the verbs.
 type Doc interface {
+	fmt.Formatter
 	doc()
 }
 
@@ -209,13 +424,32 @@ func (text) doc() {}
 var _ Doc = text("")
 
 type line struct {
-	IsBreak bool
+	Literal bool
 }
 
 func (line) doc() {}
 
+// indentation returns the indentation of the line after the break, given the current indentation level.
+// A literal line break ignores the indentation level.
+func (l line) indentation(i int) int {
+	if l.Literal {
+		return 0
+	}
+
+	return i
+}
+
 var _ Doc = line{}
 
+type flatAlt struct {
+	Broken Doc
+	Flat   Doc
+}
+
+func (flatAlt) doc() {}
+
+var _ Doc = flatAlt{}
+
 type cat struct {
 	First  Doc
 	Second Doc
@@ -243,6 +477,22 @@ func (union) doc() {}
 
 var _ Doc = union{}
 
+type annotate struct {
+	Ann any
+	Doc Doc
+}
+
+func (annotate) doc() {}
+
+var _ Doc = annotate{}
+
+// annotationEnd marks the end of an annotated document on the work list of a renderer.
+type annotationEnd struct{}
+
+func (annotationEnd) doc() {}
+
+var _ Doc = annotationEnd{}
+
 type column func(int) Doc
 
 func (column) doc() {}
@@ -255,6 +505,12 @@ func (nesting) doc() {}
 
 var _ Doc = nesting(func(int) Doc { return empty{} })
 
+type withOptions func(RenderOptions) Doc
+
+func (withOptions) doc() {}
+
+var _ Doc = withOptions(func(RenderOptions) Doc { return empty{} })
+
 // Empty has no content.
 func Empty() Doc {
 	return empty{}
@@ -270,22 +526,110 @@ func Char(c rune) Doc {
 }
 
 // Text represents a string of text.
+// Newlines in the string are line breaks that cannot be undone by `Group`; the lines after them start at the current indentation level.
+// Empty lines are left without indentation.
 func Text(s string) Doc {
 	if s == "" {
 		return Empty()
 	}
 
-	return text(s)
+	if !strings.Contains(s, "\n") {
+		return text(s)
+	}
+
+	lines := strings.Split(s, "\n")
+	doc := Text(lines[0])
+	for i, l := range lines[1:] {
+		// An empty line other than the last one gets no indentation, so it has no trailing white space.
+		var br Doc = line{}
+		if l == "" && i < len(lines)-2 {
+			br = line{Literal: true}
+		}
+
+		doc = Hcat(doc, br, Text(l))
+	}
+
+	return doc
+}
+
+// Verbatim represents a pre-formatted block of text, such as an embedded SQL query.
+// The indentation common to all non-blank lines is removed, and the lines are aligned to the column where the block starts,
+// so they keep their indentation relative to each other.
+//
+//	Hsep(Text("query :="), Verbatim("    SELECT *\n      FROM t\n    WHERE x"))
+//
+// will render as:
+//
+//	query := SELECT *
+//	           FROM t
+//	         WHERE x
+func Verbatim(s string) Doc {
+	lines := strings.Split(s, "\n")
+
+	prefix := ""
+	found := false
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+
+		indent := l[:len(l)-len(strings.TrimLeft(l, " \t"))]
+		if !found {
+			prefix, found = indent, true
+			continue
+		}
+
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	for i, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			lines[i] = ""
+		} else {
+			lines[i] = strings.TrimPrefix(l, prefix)
+		}
+	}
+
+	return Align(Text(strings.Join(lines, "\n")))
 }
 
 // Line advances to the next line and indents to the current indentation level. `Line()` behaves like `Char(' ')` if the line break is undone by `Group`.
 func Line() Doc {
-	return line{IsBreak: false}
+	return FlatAlt(line{}, Text(" "))
 }
 
 // LineBreak advances to the next line and indents to the current indentation level. `LineBreak()` behaves like `Empty()` if the line break is undone by `Group`.
 func LineBreak() Doc {
-	return line{IsBreak: true}
+	return FlatAlt(line{}, Empty())
+}
+
+// HardLine always advances to the next line and indents to the current indentation level, even inside `Group`.
+// A group that contains a hard line is never put on one line, so
+//
+//	Group(Vsep(Text("f(x)"), Hcat(Text("// comment"), HardLine(), Text("g(x)"))))
+//
+// will render `f(x)`, the comment and `g(x)` on separate lines however wide the page is, instead of putting `f(x)` on the line of the comment.
+func HardLine() Doc {
+	return line{}
+}
+
+// LiteralLine is a `HardLine` that does not indent the next line, for text such as heredocs whose lines must stay as they are.
+func LiteralLine() Doc {
+	return line{Literal: true}
+}
+
+// FlatAlt renders as broken by default, but as flat when its line breaks are undone by `Group`.
+//
+//	Group(Vcat(Text("a"), Beside(Text("b"), FlatAlt(Char(','), Empty()))))
+//
+// will render as `ab` if it fits the page, or otherwise as:
+//
+//	a
+//	b,
+func FlatAlt(broken, flat Doc) Doc {
+	return flatAlt{Broken: broken, Flat: flat}
 }
 
 // Beside concatenates two documents horizontally.
@@ -316,7 +660,21 @@ func Nesting(f func(int) Doc) Doc {
 	return nesting(f)
 }
 
-// Group undoes all line breaks in the document.
+// WithOptions creates a document that depends on the options it is rendered with, such as the page width.
+// `RenderCompact` passes options with an `Unbounded` page width.
+func WithOptions(f func(RenderOptions) Doc) Doc {
+	return withOptions(f)
+}
+
+// Annotate attaches a value to the document, such as a syntax class, a style or a node ID.
+// Annotations do not affect the layout; they show up in the rendered SimpleDoc as an `SAnnPush`
+// before the document and an `SAnnPop` after it, for renderers that give them a meaning.
+func Annotate(ann any, doc Doc) Doc {
+	return annotate{Ann: ann, Doc: doc}
+}
+
+// Group undoes all line breaks in the document if the result fits the page.
+// A document that contains a `HardLine` or a `LiteralLine` cannot be undone, so `Group` leaves it broken.
 func Group(doc Doc) Doc {
 	return union{
 		Longer:  flatten(doc),
@@ -324,50 +682,74 @@ func Group(doc Doc) Doc {
 	}
 }
 
+// flattened is a document whose line breaks are undone.
+// The renderers flatten it lazily while laying it out, so `Group` does not have to copy the whole document.
+type flattened struct {
+	Doc Doc
+}
+
+func (flattened) doc() {}
+
+var _ Doc = flattened{}
+
 // flatten removes all line breaks from the document.
 func flatten(doc Doc) Doc {
-	switch d := doc.(type) {
-	case cat:
-		return cat{
-			First:  flatten(d.First),
-			Second: flatten(d.Second),
-		}
-	case char:
+	if d, ok := doc.(flattened); ok {
 		return d
-	case column:
-		return column(func(width int) Doc {
-			return flatten(d(width))
-		})
-	case empty:
-		return d
-	case line:
-		if d.IsBreak {
-			return Empty()
-		}
-		return Text(" ")
-	case nest:
-		return nest{
-			Indent: d.Indent,
-			Doc:    flatten(d.Doc),
-		}
-	case nesting:
-		return nesting(func(width int) Doc {
-			return flatten(d(width))
-		})
-	case text:
-		return d
-	case union:
-		return flatten(d.Longer)
-	default:
-		panic(fmt.Sprintf("unexpected pprint.Doc: %#v", d))
 	}
+
+	return flattened{Doc: doc}
 }
 
 // SimpleDoc represents a rendered document in a simplified form for output.
+// It is a list of nodes ending in `SEmpty`; every other node has a `Rest` method returning the nodes after it.
+// `Tokens` iterates over the nodes.
+//
+// Every node implements `io.WriterTo`, writing the document from that node on like `Display`.
 type SimpleDoc interface {
+	io.WriterTo
 	simpleDoc()
 }
 
+// Tokens returns an iterator over the nodes of the SimpleDoc, excluding the final `SEmpty`.
+// With Go 1.23 or later, it can be used as an `iter.Seq[SimpleDoc]`:
+//
+//	for node := range Tokens(x) {
+//		if l, ok := node.(SLine); ok {
+//			fmt.Println("line break, indented by", l.Indent())
+//		}
+//	}
+func Tokens(x SimpleDoc) func(yield func(SimpleDoc) bool) {
+	return func(yield func(SimpleDoc) bool) {
+		for x := x; ; {
+			var rest SimpleDoc
+
+			switch d := x.(type) {
+			case SEmpty:
+				return
+			case SChar:
+				rest = d.rest
+			case SText:
+				rest = d.rest
+			case SLine:
+				rest = d.rest
+			case SAnnPush:
+				rest = d.rest
+			case SAnnPop:
+				rest = d.rest
+			default:
+				panic(fmt.Sprintf("unexpected pprint.SimpleDoc: %#v", d))
+			}
+
+			if !yield(x) {
+				return
+			}
+
+			x = rest
+		}
+	}
+}
+
 // SEmpty represents an empty SimpleDoc node.
 type SEmpty struct{}
 
@@ -383,6 +765,16 @@ type SChar struct {
 
 func (SChar) simpleDoc() {}
 
+// Char returns the character.
+func (s SChar) Char() rune {
+	return s.char
+}
+
+// Rest returns the nodes after the character.
+func (s SChar) Rest() SimpleDoc {
+	return s.rest
+}
+
 var _ SimpleDoc = SChar{}
 
 // SText represents a string of text in a SimpleDoc.
@@ -393,18 +785,73 @@ type SText struct {
 
 func (SText) simpleDoc() {}
 
+// Text returns the text.
+func (s SText) Text() string {
+	return s.text
+}
+
+// Rest returns the nodes after the text.
+func (s SText) Rest() SimpleDoc {
+	return s.rest
+}
+
 var _ SimpleDoc = SText{}
 
 // SLine represents a line break and indentation in a SimpleDoc.
 type SLine struct {
 	indent int
+	tab    int // the width of a tab if the indentation is written with tabs, or zero
 	rest   SimpleDoc
 }
 
 func (SLine) simpleDoc() {}
 
+// Indent returns the number of columns the line after the break is indented by.
+func (s SLine) Indent() int {
+	return s.indent
+}
+
+// Rest returns the nodes after the line break.
+func (s SLine) Rest() SimpleDoc {
+	return s.rest
+}
+
 var _ SimpleDoc = SLine{}
 
+// SAnnPush marks the start of an annotated part of a SimpleDoc.
+type SAnnPush struct {
+	ann  any
+	rest SimpleDoc
+}
+
+func (SAnnPush) simpleDoc() {}
+
+// Annotation returns the value attached by `Annotate`.
+func (s SAnnPush) Annotation() any {
+	return s.ann
+}
+
+// Rest returns the nodes after the start of the annotation.
+func (s SAnnPush) Rest() SimpleDoc {
+	return s.rest
+}
+
+var _ SimpleDoc = SAnnPush{}
+
+// SAnnPop marks the end of the innermost annotated part of a SimpleDoc.
+type SAnnPop struct {
+	rest SimpleDoc
+}
+
+func (SAnnPop) simpleDoc() {}
+
+// Rest returns the nodes after the end of the annotation.
+func (s SAnnPop) Rest() SimpleDoc {
+	return s.rest
+}
+
+var _ SimpleDoc = SAnnPop{}
+
 // Renderers
 
 // Docs is a list of indentation/document pairs.
@@ -412,6 +859,7 @@ type Docs struct {
 	Rest   *Docs
 	Indent int
 	Doc    Doc
+	flat   bool
 }
 
 // Cons constructs a Docs list node with the given indentation and document.
@@ -423,180 +871,585 @@ func Cons(indent int, doc Doc, rest *Docs) *Docs {
 	}
 }
 
+// consFlat is like Cons, but the line breaks of doc are undone when flat is true.
+func consFlat(indent int, flat bool, doc Doc, rest *Docs) *Docs {
+	return &Docs{
+		Rest:   rest,
+		Indent: indent,
+		Doc:    doc,
+		flat:   flat,
+	}
+}
+
 // Nil returns an empty Docs list.
 func Nil() *Docs {
 	return nil
 }
 
+// Unbounded is a page width under which no group is ever broken to fit the page.
+// Any negative `RenderOptions.PageWidth` is treated the same way.
+const Unbounded = -1
+
+// LayoutMode selects how `Render` decides whether a group fits the page.
+type LayoutMode int
+
+const (
+	// LayoutWadler puts a group on one line if the rest of the line after it fits the page.
+	// This is the algorithm of wl-pprint.
+	LayoutWadler LayoutMode = iota
+
+	// LayoutSmart puts a group on one line only if the following lines that are indented deeper than the group
+	// fit the page as well, so a flat group is not chosen when it pushes the content after it over the page.
+	LayoutSmart
+
+	// LayoutOptimal chooses the layout of all groups together so that the fewest columns overflow the page and ribbon,
+	// and among those layouts the one with the fewest lines. Like the other modes, it puts a group on one line
+	// only if the group itself fits. See `optimal` for the bounds of the search.
+	LayoutOptimal
+)
+
+// RenderOptions configures the layout of a document by `Render`.
+type RenderOptions struct {
+	// PageWidth is the maximal number of columns on a line, or `Unbounded`.
+	PageWidth int
+
+	// RibbonFraction is the fraction of the page width that the non-indentation characters of a line may take.
+	// Values outside (0, 1], including the zero value, mean that the ribbon spans the whole page.
+	RibbonFraction float64
+
+	// Layout selects the layout algorithm. The zero value is `LayoutWadler`.
+	Layout LayoutMode
+
+	// TextWidth returns the number of columns a text or character occupies.
+	// If it is nil, `StringWidth` is used.
+	TextWidth func(string) int
+
+	// TabWidth is the distance between tab stops. Zero or negative values mean 8 columns.
+	TabWidth int
+
+	// Tabs selects whether tab characters in text are kept or replaced with spaces. The zero value is `TabsPreserve`.
+	Tabs TabMode
+
+	// IndentWithTabs writes the indentation after line breaks as tabs, followed by spaces for the columns
+	// short of the next tab stop, the way gofmt indents.
+	IndentWithTabs bool
+}
+
+// TabMode selects how `Render` treats tab characters in text.
+type TabMode int
+
+const (
+	// TabsPreserve writes tabs as they are. The column after a tab is the next tab stop.
+	TabsPreserve TabMode = iota
+
+	// TabsExpand replaces every tab with the spaces up to the next tab stop.
+	TabsExpand
+)
+
+const defaultTabWidth = 8
+
+// tabWidth returns the distance between tab stops.
+func (opts RenderOptions) tabWidth() int {
+	if opts.TabWidth <= 0 {
+		return defaultTabWidth
+	}
+
+	return opts.TabWidth
+}
+
+// measure returns the function that measures texts.
+func (opts RenderOptions) measure() func(string) int {
+	if opts.TextWidth == nil {
+		return StringWidth
+	}
+
+	return opts.TextWidth
+}
+
+// advanceColumn returns the column after the text written at column k, moving to the next tab stop at every tab.
+func advanceColumn(k int, s string, measure func(string) int, tabWidth int) int {
+	for {
+		j := strings.IndexByte(s, '\t')
+		if j < 0 {
+			return k + measure(s)
+		}
+
+		k += measure(s[:j])
+		k += tabWidth - k%tabWidth
+		s = s[j+1:]
+	}
+}
+
+// expandTabs replaces the tabs in the text written at column k with spaces up to the next tab stop.
+func expandTabs(k int, s string, measure func(string) int, tabWidth int) string {
+	if strings.IndexByte(s, '\t') < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for {
+		j := strings.IndexByte(s, '\t')
+		if j < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+
+		b.WriteString(s[:j])
+		k += measure(s[:j])
+		b.WriteString(Spaces(tabWidth - k%tabWidth))
+		k += tabWidth - k%tabWidth
+		s = s[j+1:]
+	}
+}
+
+// DefaultRenderOptions returns the options used by `PutDoc` and `FputDoc`:
+// a page width of 80 columns and a ribbon as wide as the page.
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{
+		PageWidth:      80,
+		RibbonFraction: 1,
+	}
+}
+
+// flatWidth returns the number of columns of the widest line of the document laid out flat with the options.
+func (opts RenderOptions) flatWidth(doc Doc) int {
+	measure, tabWidth := opts.measure(), opts.tabWidth()
+
+	opts.PageWidth = Unbounded
+
+	widest, w := 0, 0
+	for x := Render(opts, flatten(doc)); ; {
+		switch d := x.(type) {
+		case SEmpty:
+			return max(widest, w)
+		case SChar:
+			w = advanceColumn(w, string(d.char), measure, tabWidth)
+			x = d.rest
+		case SText:
+			w = advanceColumn(w, d.text, measure, tabWidth)
+			x = d.rest
+		case SLine:
+			widest, w = max(widest, w), d.indent
+			x = d.rest
+		case SAnnPush:
+			x = d.rest
+		case SAnnPop:
+			x = d.rest
+		default:
+			panic(fmt.Sprintf("unexpected pprint.SimpleDoc: %#v", d))
+		}
+	}
+}
+
+// ribbonWidth returns the maximal number of non-indentation characters on a line.
+func (opts RenderOptions) ribbonWidth() int {
+	rfrac := opts.RibbonFraction
+	if rfrac <= 0 || rfrac > 1 {
+		rfrac = 1
+	}
+
+	return max(0, min(opts.PageWidth, int(math.Round(float64(opts.PageWidth)*rfrac))))
+}
+
+// Render lays out the document according to the options.
+func Render(opts RenderOptions, x Doc) SimpleDoc {
+	pretty := pretty{
+		opts:      opts,
+		w:         opts.PageWidth,
+		r:         opts.ribbonWidth(),
+		unbounded: opts.PageWidth < 0,
+		smart:     opts.Layout == LayoutSmart,
+		textWidth: opts.measure(),
+		tabWidth:  opts.tabWidth(),
+		expand:    opts.Tabs == TabsExpand,
+	}
+
+	if opts.IndentWithTabs {
+		pretty.indentTab = pretty.tabWidth
+	}
+
+	if opts.Layout == LayoutOptimal {
+		return pretty.optimal(Cons(0, x, Nil()))
+	}
+
+	return pretty.best(Cons(0, x, Nil()))
+}
+
 // RenderPretty renders the document with pretty printing.
 // `RenderPretty(rfrac, w, x)` will render the document `x` with a page width of `w` and a ribbon width of `rfrac * w`.
 // The ribbon width is the maximal amount of non-indentation characters on a line.
 // `rfrac` should be between 0 and 1.
 func RenderPretty(rfrac float64, w int, x Doc) SimpleDoc {
-	r := max(w, int(math.Round(float64(w)*rfrac)))
+	return Render(RenderOptions{PageWidth: w, RibbonFraction: rfrac}, x)
+}
 
-	pretty := pretty{
-		rfrac: rfrac,
-		w:     w,
-		x:     x,
-		r:     r,
+type pretty struct {
+	opts      RenderOptions
+	w         int
+	r         int
+	unbounded bool
+	smart     bool
+	textWidth func(string) int
+	tabWidth  int
+	expand    bool
+	indentTab int
+}
+
+// after returns the column after the text written at column k.
+func (p pretty) after(k int, s string) int {
+	return advanceColumn(k, s, p.textWidth, p.tabWidth)
+}
+
+// emit returns the node for the text written at column k, with its tabs expanded if the options ask for it.
+func (p pretty) emit(k int, s string) SimpleDoc {
+	if p.expand && strings.IndexByte(s, '\t') >= 0 {
+		return SText{text: expandTabs(k, s, p.textWidth, p.tabWidth)}
 	}
 
-	return pretty.best(0, 0, Cons(0, x, Nil()))
+	return SText{text: s}
 }
 
-type pretty struct {
-	rfrac float64
-	w     int
-	x     Doc
-	r     int
+// newline returns the node for a line break followed by the indentation.
+func (p pretty) newline(indent int) SimpleDoc {
+	return SLine{indent: indent, tab: p.indentTab}
 }
 
-func (p pretty) best(n int, k int, docs *Docs) SimpleDoc {
-	if docs == Nil() {
-		return SEmpty{}
+// best lays out docs using the list itself as an explicit work stack,
+// so neither the Go stack nor the depth of the result grows with the size of the document.
+func (p pretty) best(docs *Docs) SimpleDoc {
+	var (
+		b simpleDocBuilder
+		n int
+		k int
+	)
+
+	for docs != Nil() {
+		i := docs.Indent
+		d := docs.Doc
+		flat := docs.flat
+		docs = docs.Rest
+
+		switch d := d.(type) {
+		case empty:
+		case char:
+			if d == '\t' && p.expand {
+				b.add(p.emit(k, string(d)))
+			} else {
+				b.add(SChar{char: rune(d)})
+			}
+			k = p.after(k, string(d))
+		case text:
+			b.add(p.emit(k, string(d)))
+			k = p.after(k, string(d))
+		case line:
+			j := d.indentation(i)
+			b.add(p.newline(j))
+			n, k = j, j
+		case flatAlt:
+			if flat {
+				docs = consFlat(i, flat, d.Flat, docs)
+			} else {
+				docs = consFlat(i, flat, d.Broken, docs)
+			}
+		case cat:
+			docs = consFlat(i, flat, d.First, consFlat(i, flat, d.Second, docs))
+		case nest:
+			docs = consFlat(i+d.Indent, flat, d.Doc, docs)
+		case flattened:
+			docs = consFlat(i, true, d.Doc, docs)
+		case annotate:
+			b.add(SAnnPush{ann: d.Ann})
+			docs = consFlat(i, flat, d.Doc, consFlat(i, flat, annotationEnd{}, docs))
+		case annotationEnd:
+			b.add(SAnnPop{})
+		case union:
+			if longer := consFlat(i, flat, d.Longer, docs); flat || p.fits(p.remaining(n, k), k, min(i, k), longer) {
+				docs = longer
+			} else {
+				docs = consFlat(i, flat, d.Shorter, docs)
+			}
+		case column:
+			docs = consFlat(i, flat, d(k), docs)
+		case nesting:
+			docs = consFlat(i, flat, d(i), docs)
+		case withOptions:
+			docs = consFlat(i, flat, d(p.opts), docs)
+		default:
+			panic(fmt.Sprintf("unexpected pprint.Doc: %#v", d))
+		}
 	}
 
-	i := docs.Indent
-	d := docs.Doc
-	ds := docs.Rest
+	return b.build()
+}
 
-	switch d := d.(type) {
-	case empty:
-		return p.best(n, k, ds)
-	case char:
-		return SChar{
-			char: rune(d),
-			rest: p.best(n, k+1, ds),
-		}
-	case text:
-		return SText{
-			text: string(d),
-			rest: p.best(n, k+len(d), ds),
+// remaining returns the number of columns left on a line indented by n at column k.
+func (p pretty) remaining(n, k int) int {
+	if p.unbounded {
+		return math.MaxInt
+	}
+
+	return min(p.w-k, p.r-k+n)
+}
+
+// fits reports whether the first line of the layout of docs fits in w columns.
+// It lays out docs lazily, the way best would, and stops at the first line break,
+// so the cost of a check is bounded by the length of one line instead of the whole document.
+// In the smart layout mode, it goes on through the lines indented deeper than m.
+//
+// A union met on the way is decided like best decides it: the longer alternative is tried first,
+// and the shorter one only if the longer one overflows. Because both alternatives continue with
+// the same rest of the list, a visited position (the rest list together with the column) that failed
+// once fails again, which keeps nested groups from being explored exponentially often.
+//
+// A hard line break met in a flattened document cannot be laid out flat, so it fails like an overflow.
+func (p pretty) fits(w int, k int, m int, docs *Docs) bool {
+	type state struct {
+		w    int
+		k    int
+		docs *Docs
+	}
+
+	var (
+		alternatives []state
+		failed       map[state]struct{}
+	)
+
+	for {
+		if w < 0 {
+			if len(alternatives) == 0 {
+				return false
+			}
+
+			last := alternatives[len(alternatives)-1]
+			alternatives = alternatives[:len(alternatives)-1]
+			w, k, docs = last.w, last.k, last.docs
+
+			continue
 		}
-	case line:
-		return SLine{
-			indent: i,
-			rest:   p.best(i, i, ds),
+
+		if docs == Nil() {
+			return true
 		}
-	case cat:
-		return p.best(n, k, Cons(i, d.First, Cons(i, d.Second, ds)))
-	case nest:
-		return p.best(n, k, Cons(i+d.Indent, d.Doc, ds))
-	case union:
-		width := min(p.w-k, p.r-k+n)
-
-		if x := p.best(n, k, Cons(i, d.Longer, ds)); fits(width, x) {
-			return x
+
+		if len(alternatives) > 0 {
+			s := state{w: w, k: k, docs: docs}
+			if _, ok := failed[s]; ok {
+				w = -1
+				continue
+			}
+
+			if failed == nil {
+				failed = make(map[state]struct{})
+			}
+			failed[s] = struct{}{}
 		}
 
-		return p.best(n, k, Cons(i, d.Shorter, ds))
-	case column:
-		return p.best(n, k, Cons(i, d(k), ds))
-	case nesting:
-		return p.best(n, k, Cons(i, d(i), ds))
-	default:
-		panic(fmt.Sprintf("unexpected pprint.Doc: %#v", d))
-	}
-}
+		i := docs.Indent
+		d := docs.Doc
+		flat := docs.flat
+		docs = docs.Rest
 
-func fits(w int, x SimpleDoc) bool {
-	if w < 0 {
-		return false
-	}
+		// On an unbounded page, only a hard line break in a flattened document can fail.
+		if _, ok := d.(flattened); p.unbounded && !flat && !ok {
+			return true
+		}
 
-	switch x := x.(type) {
-	case SEmpty:
-		return true
-	case SChar:
-		return fits(w-1, x.rest)
-	case SText:
-		s := x.text
-		l := len(s)
-		return fits(w-l, x.rest)
-	case SLine:
-		return true
-	default:
-		panic(fmt.Sprintf("unexpected pprint.SimpleDoc: %#v", x))
+		switch d := d.(type) {
+		case empty:
+		case char:
+			l := p.after(k, string(d)) - k
+			w -= l
+			k += l
+		case text:
+			l := p.after(k, string(d)) - k
+			w -= l
+			k += l
+		case line:
+			if flat {
+				w = -1
+				continue
+			}
+
+			j := d.indentation(i)
+			if !p.smart || j <= m {
+				return true
+			}
+
+			w, k = p.remaining(j, j), j
+		case flatAlt:
+			if flat {
+				docs = consFlat(i, flat, d.Flat, docs)
+			} else {
+				docs = consFlat(i, flat, d.Broken, docs)
+			}
+		case cat:
+			docs = consFlat(i, flat, d.First, consFlat(i, flat, d.Second, docs))
+		case nest:
+			docs = consFlat(i+d.Indent, flat, d.Doc, docs)
+		case flattened:
+			docs = consFlat(i, true, d.Doc, docs)
+		case annotate:
+			docs = consFlat(i, flat, d.Doc, docs)
+		case annotationEnd:
+		case union:
+			if !flat {
+				alternatives = append(alternatives, state{w: w, k: k, docs: consFlat(i, flat, d.Shorter, docs)})
+			}
+			docs = consFlat(i, flat, d.Longer, docs)
+		case column:
+			docs = consFlat(i, flat, d(k), docs)
+		case nesting:
+			docs = consFlat(i, flat, d(i), docs)
+		case withOptions:
+			docs = consFlat(i, flat, d(p.opts), docs)
+		default:
+			panic(fmt.Sprintf("unexpected pprint.Doc: %#v", d))
+		}
 	}
 }
 
 // RenderCompact renders the document without pretty-printing, producing a SimpleDoc.
 func RenderCompact(x Doc) SimpleDoc {
-	return scan(0, []Doc{x})
+	var (
+		b     simpleDocBuilder
+		k     int
+		stack = []Doc{x}
+	)
+
+	for len(stack) > 0 {
+		d := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		switch d := d.(type) {
+		case empty:
+		case char:
+			b.add(SChar{char: rune(d)})
+			k = advanceColumn(k, string(d), StringWidth, defaultTabWidth)
+		case text:
+			b.add(SText{text: string(d)})
+			k = advanceColumn(k, string(d), StringWidth, defaultTabWidth)
+		case line:
+			b.add(SLine{indent: 0})
+			k = 0
+		case flatAlt:
+			stack = append(stack, d.Broken)
+		case cat:
+			stack = append(stack, d.Second, d.First)
+		case nest:
+			stack = append(stack, d.Doc)
+		case flattened:
+			stack = append(stack, d.Doc)
+		case annotate:
+			b.add(SAnnPush{ann: d.Ann})
+			stack = append(stack, annotationEnd{}, d.Doc)
+		case annotationEnd:
+			b.add(SAnnPop{})
+		case union:
+			stack = append(stack, d.Shorter)
+		case column:
+			stack = append(stack, d(k))
+		case nesting:
+			stack = append(stack, d(0))
+		case withOptions:
+			stack = append(stack, d(RenderOptions{PageWidth: Unbounded}))
+		default:
+			panic(fmt.Sprintf("unexpected pprint.Doc: %#v", d))
+		}
+	}
+
+	return b.build()
 }
 
-func scan(k int, ds []Doc) SimpleDoc {
-	if len(ds) == 0 {
-		return SEmpty{}
-	}
+// simpleDocBuilder collects the nodes of a layout in order and links them into a SimpleDoc.
+type simpleDocBuilder struct {
+	nodes []SimpleDoc
+}
 
-	d := ds[0]
-	ds = ds[1:]
+// add appends a node. Its rest is filled in by build.
+func (b *simpleDocBuilder) add(x SimpleDoc) {
+	b.nodes = append(b.nodes, x)
+}
 
-	switch d := d.(type) {
-	case empty:
-		return scan(k, ds)
-	case char:
-		return SChar{
-			char: rune(d),
-			rest: scan(k+1, ds),
-		}
-	case text:
-		return SText{
-			text: string(d),
-			rest: scan(k+len(string(d)), ds),
-		}
-	case line:
-		return SLine{
-			indent: 0,
-			rest:   scan(0, ds),
+// build links the collected nodes from the last to the first, so no recursion is needed.
+func (b *simpleDocBuilder) build() SimpleDoc {
+	var rest SimpleDoc = SEmpty{}
+
+	for j := len(b.nodes) - 1; j >= 0; j-- {
+		switch x := b.nodes[j].(type) {
+		case SChar:
+			x.rest = rest
+			rest = x
+		case SText:
+			x.rest = rest
+			rest = x
+		case SLine:
+			x.rest = rest
+			rest = x
+		case SAnnPush:
+			x.rest = rest
+			rest = x
+		case SAnnPop:
+			x.rest = rest
+			rest = x
+		default:
+			panic(fmt.Sprintf("unexpected pprint.SimpleDoc: %#v", x))
 		}
-	case cat:
-		return scan(k, append([]Doc{d.First, d.Second}, ds...))
-	case nest:
-		return scan(k, append([]Doc{d.Doc}, ds...))
-	case union:
-		return scan(k, append([]Doc{d.Shorter}, ds...))
-	case column:
-		return scan(k, append([]Doc{d(k)}, ds...))
-	case nesting:
-		return scan(k, append([]Doc{d(0)}, ds...))
-	default:
-		panic(fmt.Sprintf("unexpected pprint.Doc: %#v", d))
 	}
+
+	return rest
 }
 
-// Display writes the rendered SimpleDoc to the given writer.
+// Display writes the rendered SimpleDoc to the given writer. Annotations are ignored.
+// The output is written directly to a `*strings.Builder`, `*bytes.Buffer` or `*bufio.Writer`.
+// For any other writer it is buffered, and flushed before Display returns.
 func Display(w io.Writer, x SimpleDoc) error {
-	switch x := x.(type) {
-	case SEmpty:
-		return nil
-	case SChar:
-		if _, err := fmt.Fprint(w, string(x.char)); err != nil {
-			return err
-		}
+	_, err := display(w, x)
 
-		return Display(w, x.rest)
-	case SText:
-		if _, err := fmt.Fprint(w, x.text); err != nil {
-			return err
-		}
+	return err
+}
 
-		return Display(w, x.rest)
-	case SLine:
-		if _, err := fmt.Fprintf(w, "\n%s", indentation(x.indent)); err != nil {
-			return err
+func display(w io.Writer, x SimpleDoc) (int64, error) {
+	s := newSink(w)
+
+	for s.err == nil {
+		switch d := x.(type) {
+		case SEmpty:
+			return s.flush()
+		case SChar:
+			s.writeRune(d.char)
+			x = d.rest
+		case SText:
+			s.writeString(d.text)
+			x = d.rest
+		case SLine:
+			s.writeLine(d)
+			x = d.rest
+		case SAnnPush:
+			x = d.rest
+		case SAnnPop:
+			x = d.rest
+		default:
+			panic(fmt.Sprintf("unexpected pprint.SimpleDoc: %#v", d))
 		}
-
-		return Display(w, x.rest)
-	default:
-		panic(fmt.Sprintf("unexpected pprint.SimpleDoc: %#v", x))
 	}
+
+	return s.n, s.err
 }
 
-func indentation(n int) string {
-	return fmt.Sprintf("%*s", n, "")
+// Indentation returns the indentation after the line break as it is written,
+// with tabs if the document was rendered with `RenderOptions.IndentWithTabs`.
+func (l SLine) Indentation() string {
+	tabs, spaces := l.split()
+
+	return strings.Repeat("\t", tabs) + Spaces(spaces)
+}
+
+// RenderString lays out the document according to the options and returns the output of `Display`.
+func RenderString(doc Doc, opts RenderOptions) string {
+	var b strings.Builder
+	_ = Display(&b, Render(opts, doc)) // writing to a strings.Builder does not fail
+
+	return b.String()
 }
 
 // PutDoc writes the pretty-printed document to standard output.
@@ -604,11 +1457,9 @@ func PutDoc(doc Doc) error {
 	return FputDoc(os.Stdout, doc)
 }
 
-// FputDoc writes the pretty-printed document to the given writer.
+// FputDoc writes the pretty-printed document to the given writer using `DefaultRenderOptions`.
 func FputDoc(w io.Writer, doc Doc) error {
-	simpleDoc := RenderPretty(0.4, 80, doc)
-
-	return Display(w, simpleDoc)
+	return Display(w, Render(DefaultRenderOptions(), doc))
 }
 
 // Pretty is an interface for types that can be pretty-printed as a Doc.
@@ -21,6 +21,9 @@ func TestPprint(t *testing.T) {
 		longCommaFillCat(),
 		fillBreak(),
 		fill(),
+		manyBrokenGroups(),
+		flatAltTrailingComma(),
+		flatAltBlock(),
 	}
 
 	for _, test := range tests {
@@ -45,6 +48,526 @@ func TestPprint(t *testing.T) {
 	}
 }
 
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	indented := pprint.Nest(8, pprint.Vsep(
+		pprint.Text("begin"),
+		pprint.Sep(pprint.Text("aaaa"), pprint.Text("bbbb"), pprint.Text("cccc")),
+	))
+
+	words := make([]pprint.Doc, 30)
+	for i := range words {
+		words[i] = pprint.Text("word")
+	}
+
+	tests := []struct {
+		name string
+		opts pprint.RenderOptions
+		doc  pprint.Doc
+		want string
+	}{
+		{
+			name: "Whole Ribbon",
+			opts: pprint.RenderOptions{PageWidth: 40, RibbonFraction: 1},
+			doc:  indented,
+			want: "begin\n        aaaa bbbb cccc",
+		},
+		{
+			name: "Narrow Ribbon",
+			opts: pprint.RenderOptions{PageWidth: 40, RibbonFraction: 0.25},
+			doc:  indented,
+			want: "begin\n        aaaa\n        bbbb\n        cccc",
+		},
+		{
+			name: "Zero Ribbon Fraction",
+			opts: pprint.RenderOptions{PageWidth: 40},
+			doc:  indented,
+			want: "begin\n        aaaa bbbb cccc",
+		},
+		{
+			name: "Unbounded",
+			opts: pprint.RenderOptions{PageWidth: pprint.Unbounded},
+			doc:  pprint.Sep(words...),
+			want: strings.TrimSuffix(strings.Repeat("word ", 30), " "),
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.Display(&got, pprint.Render(test.opts, test.doc)); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, got.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	t.Parallel()
+
+	args := func(annotate func(string, pprint.Doc) pprint.Doc) pprint.Doc {
+		words := []string{"alpha", "beta", "gamma", "delta", "epsilon", "zeta"}
+		docs := make([]pprint.Doc, len(words))
+		for i, w := range words {
+			docs[i] = annotate("arg", pprint.Text(w))
+		}
+
+		return annotate("call", pprint.Hcat(
+			annotate("keyword", pprint.Text("call")),
+			pprint.Char('('),
+			pprint.Align(pprint.Cat(pprint.Punctuate(pprint.Text(", "), docs...)...)),
+			pprint.Char(')'),
+		))
+	}
+
+	plain := args(func(_ string, doc pprint.Doc) pprint.Doc { return doc })
+	annotated := args(func(ann string, doc pprint.Doc) pprint.Doc { return pprint.Annotate(ann, doc) })
+
+	for _, w := range []int{80, 20} {
+		var want, got strings.Builder
+		if err := pprint.Display(&want, pprint.RenderPretty(1, w, plain)); err != nil {
+			t.Fatal(err)
+		}
+		if err := pprint.Display(&got, pprint.RenderPretty(1, w, annotated)); err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(want.String(), got.String()); diff != "" {
+			t.Errorf("width %d: annotations changed the output (-want +got):\n%s", w, diff)
+		}
+	}
+
+	if _, ok := pprint.RenderPretty(1, 80, annotated).(pprint.SAnnPush); !ok {
+		t.Errorf("RenderPretty does not start with an SAnnPush")
+	}
+	if _, ok := pprint.RenderCompact(annotated).(pprint.SAnnPush); !ok {
+		t.Errorf("RenderCompact does not start with an SAnnPush")
+	}
+}
+
+func TestLayoutSmart(t *testing.T) {
+	t.Parallel()
+
+	fun := func(x pprint.Doc) pprint.Doc {
+		return pprint.Beside(pprint.Hang(2, pprint.Hcat(pprint.Text("fun("), pprint.SoftBreak(), x)), pprint.Char(')'))
+	}
+	list := pprint.Align(pprint.Hcat(
+		pprint.Text("[ "),
+		pprint.Cat(pprint.Text("abcdef"), pprint.Beside(pprint.Text(", "), pprint.Text("ghijklm"))),
+		pprint.Text(" ]"),
+	))
+	doc := fun(fun(fun(fun(fun(list)))))
+
+	tests := []struct {
+		name   string
+		layout pprint.LayoutMode
+		want   []string
+	}{
+		{
+			name:   "Wadler",
+			layout: pprint.LayoutWadler,
+			want: []string{
+				"fun(fun(fun(fun(fun(",
+				"                  [ abcdef",
+				"                  , ghijklm ])))))",
+			},
+		},
+		{
+			name:   "Smart",
+			layout: pprint.LayoutSmart,
+			want: []string{
+				"fun(",
+				"  fun(",
+				"    fun(",
+				"      fun(",
+				"        fun(",
+				"          [ abcdef",
+				"          , ghijklm ])))))",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.Display(&got, pprint.Render(pprint.RenderOptions{PageWidth: 26, Layout: test.layout}, doc)); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, strings.Split(got.String(), "\n")); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEnclose(t *testing.T) {
+	t.Parallel()
+
+	nums := []pprint.Doc{pprint.Text("10"), pprint.Text("200"), pprint.Text("3000")}
+
+	tests := []struct {
+		name  string
+		width int
+		doc   pprint.Doc
+		want  []string
+	}{
+		{name: "Parens", width: 80, doc: pprint.Parens(pprint.Text("x")), want: []string{"(x)"}},
+		{name: "Brackets", width: 80, doc: pprint.Brackets(pprint.Text("x")), want: []string{"[x]"}},
+		{name: "Braces", width: 80, doc: pprint.Braces(pprint.Text("x")), want: []string{"{x}"}},
+		{name: "Angles", width: 80, doc: pprint.Angles(pprint.Text("x")), want: []string{"<x>"}},
+		{name: "Squotes", width: 80, doc: pprint.Squotes(pprint.Text("x")), want: []string{"'x'"}},
+		{name: "Dquotes", width: 80, doc: pprint.Dquotes(pprint.Text("x")), want: []string{`"x"`}},
+		{name: "Empty List", width: 80, doc: pprint.List(), want: []string{"[]"}},
+		{name: "Singleton List", width: 1, doc: pprint.List(nums[0]), want: []string{"[10]"}},
+		{name: "List", width: 80, doc: pprint.List(nums...), want: []string{"[10,200,3000]"}},
+		{
+			name:  "Vertical List",
+			width: 10,
+			doc:   pprint.List(nums...),
+			want: []string{
+				"[10",
+				",200",
+				",3000]",
+			},
+		},
+		{name: "Tupled", width: 80, doc: pprint.Tupled(nums...), want: []string{"(10,200,3000)"}},
+		{
+			name:  "Aligned Tupled",
+			width: 10,
+			doc:   pprint.Hsep(pprint.Text("f"), pprint.Tupled(nums...)),
+			want: []string{
+				"f (10",
+				"  ,200",
+				"  ,3000)",
+			},
+		},
+		{name: "SemiBraces", width: 80, doc: pprint.SemiBraces(nums...), want: []string{"{10;200;3000}"}},
+		{
+			name:  "EncloseSep",
+			width: 12,
+			doc:   pprint.EncloseSep(pprint.Text("<< "), pprint.Text(" >>"), pprint.Text(" | "), nums...),
+			want: []string{
+				"<< 10",
+				" | 200",
+				" | 3000 >>",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.Display(&got, pprint.RenderPretty(1, test.width, test.doc)); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, strings.Split(got.String(), "\n")); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReflow(t *testing.T) {
+	t.Parallel()
+
+	const text = `The quick brown fox jumps over the lazy dog.
+It was the best of times,   it was the worst of times.
+
+
+Lorem ipsum dolor sit amet.
+Consectetur adipiscing elit.`
+
+	tests := []struct {
+		name  string
+		width int
+		doc   pprint.Doc
+		want  []string
+	}{
+		{
+			name:  "Words",
+			width: 20,
+			doc:   pprint.FillSep(pprint.Words("  a  quick\tbrown\nfox jumps over the lazy dog ")...),
+			want: []string{
+				"a quick brown fox",
+				"jumps over the lazy",
+				"dog",
+			},
+		},
+		{
+			name:  "Reflow",
+			width: 30,
+			doc:   pprint.Reflow(text),
+			want: []string{
+				"The quick brown fox jumps over",
+				"the lazy dog. It was the best",
+				"of times, it was the worst of",
+				"times.",
+				"",
+				"Lorem ipsum dolor sit amet.",
+				"Consectetur adipiscing elit.",
+			},
+		},
+		{
+			name:  "Reflow Wide",
+			width: 80,
+			doc:   pprint.Reflow(text),
+			want: []string{
+				"The quick brown fox jumps over the lazy dog. It was the best of times, it was",
+				"the worst of times.",
+				"",
+				"Lorem ipsum dolor sit amet. Consectetur adipiscing elit.",
+			},
+		},
+		{
+			name:  "Reflow Nested",
+			width: 30,
+			doc:   pprint.Beside(pprint.Text("// "), pprint.Align(pprint.Reflow("Lorem ipsum dolor sit amet, consectetur adipiscing elit."))),
+			want: []string{
+				"// Lorem ipsum dolor sit amet,",
+				"   consectetur adipiscing",
+				"   elit.",
+			},
+		},
+		{
+			name:  "ReflowLines",
+			width: 30,
+			doc:   pprint.ReflowLines(text),
+			want: []string{
+				"The quick brown fox jumps over",
+				"the lazy dog.",
+				"It was the best of times, it",
+				"was the worst of times.",
+				"",
+				"Lorem ipsum dolor sit amet.",
+				"Consectetur adipiscing elit.",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.Display(&got, pprint.RenderPretty(1, test.width, test.doc)); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, strings.Split(got.String(), "\n")); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMultilineText(t *testing.T) {
+	t.Parallel()
+
+	const query = `
+		SELECT id, name
+		  FROM users
+		 WHERE active
+
+		ORDER BY name`
+
+	tests := []struct {
+		name string
+		doc  pprint.Doc
+		want []string
+	}{
+		{
+			name: "Text",
+			doc:  pprint.Text("a\nb"),
+			want: []string{"a", "b"},
+		},
+		{
+			name: "Text Nested",
+			doc:  pprint.Vsep(pprint.Text("begin"), pprint.Indent(2, pprint.Text("first\nsecond\n\nthird")), pprint.Text("end")),
+			want: []string{"begin", "  first", "  second", "", "  third", "end"},
+		},
+		{
+			name: "Text Aligned",
+			doc:  pprint.Hsep(pprint.Text("//"), pprint.Align(pprint.Text("one\ntwo"))),
+			want: []string{"// one", "   two"},
+		},
+		{
+			name: "Text Breaks Group",
+			doc:  pprint.Sep(pprint.Text("a\nb"), pprint.Text("c")),
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "Verbatim",
+			doc:  pprint.Hsep(pprint.Text("query :="), pprint.Verbatim(query)),
+			want: []string{
+				"query := ",
+				"         SELECT id, name",
+				"           FROM users",
+				"          WHERE active",
+				"",
+				"         ORDER BY name",
+			},
+		},
+		{
+			name: "Verbatim Nested",
+			doc: pprint.Vsep(
+				pprint.Text("func f() {"),
+				pprint.Indent(4, pprint.Verbatim("\t\tif x {\n\t\t\ty()\n\t\t}")),
+				pprint.Text("}"),
+			),
+			want: []string{
+				"func f() {",
+				"    if x {",
+				"    \ty()",
+				"    }",
+				"}",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.FputDoc(&got, test.doc); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, strings.Split(got.String(), "\n")); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestJustify(t *testing.T) {
+	t.Parallel()
+
+	const text = "Permission is hereby granted, free of charge, to any person obtaining a copy of this software."
+
+	tests := []struct {
+		name string
+		opts pprint.RenderOptions
+		doc  pprint.Doc
+		want []string
+	}{
+		{
+			name: "Page",
+			opts: pprint.RenderOptions{PageWidth: 30},
+			doc:  pprint.Justify(pprint.Words(text)...),
+			want: []string{
+				"Permission  is hereby granted,",
+				"free  of charge, to any person",
+				"obtaining   a   copy  of  this",
+				"software.",
+			},
+		},
+		{
+			name: "Ribbon",
+			opts: pprint.RenderOptions{PageWidth: 60, RibbonFraction: 0.5},
+			doc:  pprint.Justify(pprint.Words(text)...),
+			want: []string{
+				"Permission  is hereby granted,",
+				"free  of charge, to any person",
+				"obtaining   a   copy  of  this",
+				"software.",
+			},
+		},
+		{
+			name: "Aligned",
+			opts: pprint.RenderOptions{PageWidth: 30},
+			doc:  pprint.Hsep(pprint.Text("//"), pprint.Align(pprint.Justify(pprint.Words(text)...))),
+			want: []string{
+				"// Permission     is    hereby",
+				"   granted, free of charge, to",
+				"   any person obtaining a copy",
+				"   of this software.",
+			},
+		},
+		{
+			name: "Nested",
+			opts: pprint.RenderOptions{PageWidth: 30},
+			doc:  pprint.Nest(4, pprint.Vsep(pprint.Text("license:"), pprint.Justify(pprint.Words(text)...))),
+			want: []string{
+				"license:",
+				"    Permission    is    hereby",
+				"    granted,  free  of charge,",
+				"    to  any person obtaining a",
+				"    copy of this software.",
+			},
+		},
+		{
+			name: "Unbounded",
+			opts: pprint.RenderOptions{PageWidth: pprint.Unbounded},
+			doc:  pprint.Justify(pprint.Words(text)...),
+			want: []string{text},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.Display(&got, pprint.Render(test.opts, test.doc)); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, strings.Split(got.String(), "\n")); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLargeDocument(t *testing.T) {
+	t.Parallel()
+
+	const n = 200000
+
+	groups := make([]pprint.Doc, n)
+	words := make([]pprint.Doc, n)
+	for i := 0; i < n; i++ {
+		groups[i] = pprint.Sep(pprint.Text("key"), pprint.Text("="), pprint.Text("value"))
+		words[i] = pprint.Hsep(pprint.Text("key"), pprint.Text("="), pprint.Text("value"))
+	}
+
+	want := strings.TrimSuffix(strings.Repeat("key = value\n", n), "\n")
+
+	for name, x := range map[string]pprint.SimpleDoc{
+		"RenderPretty":  pprint.RenderPretty(1, 80, pprint.Vsep(groups...)),
+		"RenderCompact": pprint.RenderCompact(pprint.Vsep(words...)),
+	} {
+		var got strings.Builder
+		if err := pprint.Display(&got, x); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.String() != want {
+			t.Errorf("%s: output mismatch", name)
+		}
+	}
+}
+
 type test struct {
 	name      string
 	doc       pprint.Doc
@@ -266,3 +789,248 @@ func fill() test {
 		},
 	}
 }
+
+func manyBrokenGroups() test {
+	// Every group overflows, so each one is decided by looking past its flat form.
+	// Rendering the rest of the document for every decision would take exponential time here.
+	var docs []pprint.Doc
+	var wantLines []string
+	for i := 0; i < 40; i++ {
+		docs = append(docs, pprint.Sep(pprint.Text(strings.Repeat("a", 50)), pprint.Text(strings.Repeat("b", 50))))
+		wantLines = append(wantLines, strings.Repeat("a", 50), strings.Repeat("b", 50))
+	}
+
+	return test{
+		name:      "Many Broken Groups",
+		doc:       pprint.Vsep(docs...),
+		wantLines: wantLines,
+	}
+}
+
+// trailingCommaList renders the items flat as `[a, b]`, and broken with a comma after every item.
+func trailingCommaList(items ...pprint.Doc) pprint.Doc {
+	elems := make([]pprint.Doc, len(items))
+	for i, item := range items {
+		if i == len(items)-1 {
+			elems[i] = pprint.Beside(item, pprint.FlatAlt(pprint.Char(','), pprint.Empty()))
+		} else {
+			elems[i] = pprint.Beside(item, pprint.Char(','))
+		}
+	}
+
+	return pprint.Group(pprint.Vcat(
+		pprint.Beside(pprint.Char('['), pprint.Nest(2, pprint.Beside(pprint.LineBreak(), pprint.Vsep(elems...)))),
+		pprint.Char(']'),
+	))
+}
+
+func flatAltTrailingComma() test {
+	long := make([]pprint.Doc, 8)
+	for i := range long {
+		long[i] = pprint.Text(strings.Repeat("x", 10))
+	}
+
+	return test{
+		name: "FlatAlt Trailing Comma",
+		doc: pprint.Vsep(
+			trailingCommaList(pprint.Text("a"), pprint.Text("b")),
+			trailingCommaList(long[:3]...),
+			trailingCommaList(long...),
+		),
+		wantLines: []string{
+			"[a, b]",
+			"[xxxxxxxxxx, xxxxxxxxxx, xxxxxxxxxx]",
+			"[",
+			"  xxxxxxxxxx,",
+			"  xxxxxxxxxx,",
+			"  xxxxxxxxxx,",
+			"  xxxxxxxxxx,",
+			"  xxxxxxxxxx,",
+			"  xxxxxxxxxx,",
+			"  xxxxxxxxxx,",
+			"  xxxxxxxxxx,",
+			"]",
+		},
+	}
+}
+
+func flatAltBlock() test {
+	block := func(stmts ...pprint.Doc) pprint.Doc {
+		sep := pprint.FlatAlt(pprint.Line(), pprint.Text("; "))
+		body := stmts[0]
+		for _, stmt := range stmts[1:] {
+			body = pprint.Hcat(body, sep, stmt)
+		}
+
+		return pprint.Group(pprint.Vsep(
+			pprint.Beside(pprint.Char('{'), pprint.Nest(2, pprint.Beside(pprint.Line(), body))),
+			pprint.Char('}'),
+		))
+	}
+
+	return test{
+		name: "FlatAlt Block",
+		doc: pprint.Vsep(
+			block(pprint.Text("a"), pprint.Text("b")),
+			block(pprint.Text(strings.Repeat("a", 40)), pprint.Text(strings.Repeat("b", 40))),
+		),
+		wantLines: []string{
+			"{ a; b }",
+			"{",
+			"  " + strings.Repeat("a", 40),
+			"  " + strings.Repeat("b", 40),
+			"}",
+		},
+	}
+}
+
+func TestHardLine(t *testing.T) {
+	t.Parallel()
+
+	comment := pprint.Group(pprint.Nest(2, pprint.Vsep(
+		pprint.Text("f(x)"),
+		pprint.Hcat(pprint.Text("// comment"), pprint.HardLine(), pprint.Text("g(x)")),
+	)))
+	nested := pprint.Group(pprint.Sep(
+		pprint.Text("outer"),
+		pprint.Group(pprint.Sep(pprint.Text("inner"), pprint.Hcat(pprint.Text("a"), pprint.HardLine(), pprint.Text("b")))),
+	))
+	heredoc := pprint.Nest(4, pprint.Vsep(
+		pprint.Text("cat <<EOF"),
+		pprint.Hcat(pprint.Text("  body"), pprint.LiteralLine(), pprint.Text("EOF")),
+	))
+
+	tests := []struct {
+		name string
+		doc  pprint.Doc
+		want []string
+	}{
+		{
+			name: "Group Breaks",
+			doc:  comment,
+			want: []string{
+				"f(x)",
+				"  // comment",
+				"  g(x)",
+			},
+		},
+		{
+			name: "Propagates To Outer Group",
+			doc:  nested,
+			want: []string{
+				"outer",
+				"inner",
+				"a",
+				"b",
+			},
+		},
+		{
+			name: "Literal",
+			doc:  heredoc,
+			want: []string{
+				"cat <<EOF",
+				"      body",
+				"EOF",
+			},
+		},
+	}
+
+	layouts := []struct {
+		name string
+		opts pprint.RenderOptions
+	}{
+		{name: "Wadler", opts: pprint.DefaultRenderOptions()},
+		{name: "Smart", opts: pprint.RenderOptions{PageWidth: 80, Layout: pprint.LayoutSmart}},
+		{name: "Optimal", opts: pprint.RenderOptions{PageWidth: 80, Layout: pprint.LayoutOptimal}},
+		{name: "Unbounded", opts: pprint.RenderOptions{PageWidth: pprint.Unbounded}},
+	}
+
+	for _, test := range tests {
+		for _, layout := range layouts {
+			test, layout := test, layout // capture range variables
+			t.Run(test.name+"/"+layout.name, func(t *testing.T) {
+				t.Parallel()
+
+				var got strings.Builder
+				if err := pprint.Display(&got, pprint.Render(layout.opts, test.doc)); err != nil {
+					t.Fatal(err)
+				}
+
+				if diff := cmp.Diff(test.want, strings.Split(got.String(), "\n")); diff != "" {
+					t.Errorf("output mismatch (-want +got):\n%s", diff)
+				}
+			})
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	t.Parallel()
+
+	doc := pprint.Nest(2, pprint.Vsep(
+		pprint.Annotate("kw", pprint.Text("begin")),
+		pprint.Hcat(pprint.Char('x'), pprint.Text(" := 1")),
+		pprint.Text("end"),
+	))
+	x := pprint.RenderPretty(1, 80, doc)
+
+	var (
+		got   strings.Builder
+		lines = 1
+		width int
+		w     int
+		anns  []any
+	)
+	pprint.Tokens(x)(func(node pprint.SimpleDoc) bool {
+		switch d := node.(type) {
+		case pprint.SChar:
+			got.WriteRune(d.Char())
+			w++
+		case pprint.SText:
+			got.WriteString(d.Text())
+			w += len(d.Text())
+		case pprint.SLine:
+			got.WriteString("\n" + d.Indentation())
+			lines++
+			w = d.Indent()
+		case pprint.SAnnPush:
+			anns = append(anns, d.Annotation())
+		case pprint.SAnnPop:
+		default:
+			t.Errorf("unexpected node %#v", d)
+		}
+		if w > width {
+			width = w
+		}
+
+		return true
+	})
+
+	var want strings.Builder
+	if err := pprint.Display(&want, x); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(want.String(), got.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+	if lines != 3 || width != 8 {
+		t.Errorf("got %d lines of at most %d columns, want 3 lines of at most 8 columns", lines, width)
+	}
+	if diff := cmp.Diff([]any{"kw"}, anns); diff != "" {
+		t.Errorf("annotations mismatch (-want +got):\n%s", diff)
+	}
+
+	n := 0
+	pprint.Tokens(x)(func(pprint.SimpleDoc) bool {
+		n++
+		return n < 2
+	})
+	if n != 2 {
+		t.Errorf("iteration went on for %d nodes after yield returned false", n-2)
+	}
+
+	if next := x.(pprint.SAnnPush).Rest(); next.(pprint.SText).Text() != "begin" {
+		t.Errorf("Rest of the first node is %#v, want the text begin", next)
+	}
+}
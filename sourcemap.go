@@ -0,0 +1,344 @@
+package pprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Marker is the annotation `Mark` attaches to a document.
+type Marker struct {
+	ID any
+
+	// whole is shared by the parts of a marked document that `Table` or `Tree` split across lines, or nil.
+	whole *int
+}
+
+// Mark tags the document with an ID, such as the AST node it was built from,
+// so that `DisplaySpans` reports where the document ends up in the output.
+func Mark(id any, doc Doc) Doc {
+	return Annotate(Marker{ID: id}, doc)
+}
+
+// Position is a position in the output. All fields count from zero.
+type Position struct {
+	// Offset is the number of bytes before the position.
+	Offset int
+	// Line is the number of line breaks before the position.
+	Line int
+	// Column is the number of bytes between the start of the line and the position.
+	Column int
+	// UTF16Column is the number of UTF-16 code units between the start of the line and the position,
+	// the unit of columns in source maps and the Language Server Protocol.
+	UTF16Column int
+}
+
+// Span is the part of the output a marked document was written to.
+type Span struct {
+	ID    any
+	Start Position
+	// End is the position just after the document.
+	End Position
+}
+
+// DisplaySpans writes the rendered SimpleDoc to the given writer like `Display`,
+// and returns the spans of the documents tagged by `Mark`, ordered by their start.
+// An enclosing document comes before the documents it contains.
+//
+// A marked document that `Table` or `Tree` places on several lines is one span from its start on the first line
+// to its end on the last, which also covers the output between its lines, such as guides and other cells.
+func DisplaySpans(w io.Writer, x SimpleDoc) ([]Span, error) {
+	var (
+		out    = newSink(w)
+		pos    Position
+		spans  []Span
+		open   []int        // the index of the span of every unclosed annotation, or -1 if it is not a Marker
+		wholes map[*int]int // the index of the span of every split marked document
+	)
+
+	advance := func(s string) {
+		pos.Offset += len(s)
+		pos.Column += len(s)
+		pos.UTF16Column += utf16Len(s)
+	}
+
+	for out.err == nil {
+		switch d := x.(type) {
+		case SEmpty:
+			_, err := out.flush()
+
+			return spans, err
+		case SChar:
+			out.writeRune(d.char)
+			advance(string(d.char))
+			x = d.rest
+		case SText:
+			out.writeString(d.text)
+			advance(d.text)
+			x = d.rest
+		case SLine:
+			out.writeLine(d)
+			tabs, spaces := d.split()
+			pos.Offset += 1 + tabs + spaces
+			pos.Line++
+			pos.Column, pos.UTF16Column = tabs+spaces, tabs+spaces
+			x = d.rest
+		case SAnnPush:
+			if m, ok := d.ann.(Marker); ok {
+				j, ok := wholes[m.whole]
+				if !ok {
+					j = len(spans)
+					spans = append(spans, Span{ID: m.ID, Start: pos})
+
+					if m.whole != nil {
+						if wholes == nil {
+							wholes = map[*int]int{}
+						}
+						wholes[m.whole] = j
+					}
+				}
+				open = append(open, j)
+			} else {
+				open = append(open, -1)
+			}
+			x = d.rest
+		case SAnnPop:
+			if len(open) > 0 {
+				if j := open[len(open)-1]; j >= 0 {
+					spans[j].End = pos
+				}
+				open = open[:len(open)-1]
+			}
+			x = d.rest
+		default:
+			panic(fmt.Sprintf("unexpected pprint.SimpleDoc: %#v", d))
+		}
+	}
+
+	return spans, out.err
+}
+
+// RenderSpans lays out the document according to the options and returns the output of `DisplaySpans`.
+func RenderSpans(doc Doc, opts RenderOptions) (string, []Span) {
+	var b strings.Builder
+	spans, _ := DisplaySpans(&b, Render(opts, doc)) // writing to a strings.Builder does not fail
+
+	return b.String(), spans
+}
+
+// utf16Len returns the number of UTF-16 code units of the string.
+func utf16Len(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			for _, r := range s[i:] {
+				n++
+				if r >= 0x10000 {
+					n++ // a surrogate pair
+				}
+			}
+
+			return n
+		}
+		n++
+	}
+
+	return n
+}
+
+// SourceLocation is the origin of a marked document in a source file. Line and Column count from zero.
+type SourceLocation struct {
+	Source string
+	Line   int
+	Column int
+	// Name is the original name of the symbol the document stands for, or empty.
+	Name string
+}
+
+// EncodeSourceMap encodes the spans as a Source Map revision 3 for the generated file.
+// locate returns the origin of the document with the given ID, or false if it has none.
+//
+// The output from the start of a span is mapped to its origin, and the output after its end
+// to the origin of the enclosing span, or to nothing if there is none. Where spans overlap without nesting,
+// the one that starts later wins. Columns are counted in UTF-16 code units.
+func EncodeSourceMap(file string, spans []Span, locate func(id any) (SourceLocation, bool)) ([]byte, error) {
+	type event struct {
+		at     Position
+		loc    SourceLocation
+		mapped bool
+	}
+
+	sorted := append([]Span(nil), spans...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return sorted[a].Start.Offset < sorted[b].Start.Offset
+	})
+
+	type frame struct {
+		end    Position
+		loc    SourceLocation
+		mapped bool
+	}
+
+	var (
+		events []event
+		stack  []frame
+	)
+
+	// closeUntil closes the spans that end by the offset, in the order they end.
+	// Spans usually nest, but those of table cells on several lines can overlap.
+	closeUntil := func(offset int) {
+		for {
+			i := -1
+			for j, f := range stack {
+				if f.end.Offset <= offset && (i < 0 || f.end.Offset <= stack[i].end.Offset) {
+					i = j
+				}
+			}
+			if i < 0 {
+				return
+			}
+
+			end := stack[i].end
+			stack = append(stack[:i], stack[i+1:]...)
+
+			e := event{at: end}
+			if len(stack) > 0 {
+				e.loc, e.mapped = stack[len(stack)-1].loc, stack[len(stack)-1].mapped
+			}
+			events = append(events, e)
+		}
+	}
+
+	for _, s := range sorted {
+		closeUntil(s.Start.Offset)
+
+		loc, ok := locate(s.ID)
+		if !ok && len(stack) > 0 {
+			loc, ok = stack[len(stack)-1].loc, stack[len(stack)-1].mapped
+		}
+
+		events = append(events, event{at: s.Start, loc: loc, mapped: ok})
+		stack = append(stack, frame{end: s.End, loc: loc, mapped: ok})
+	}
+	closeUntil(math.MaxInt)
+
+	// Of the events at the same offset, the last one wins,
+	// and an unmapped event after another one does not change anything.
+	var deduped []event
+	for _, e := range events {
+		n := len(deduped)
+		switch {
+		case n > 0 && deduped[n-1].at.Offset == e.at.Offset:
+			deduped[n-1] = e
+		case n > 0 && !deduped[n-1].mapped && !e.mapped:
+		default:
+			deduped = append(deduped, e)
+		}
+	}
+
+	var (
+		sources     []string
+		sourceIndex = map[string]int{}
+		names       []string
+		nameIndex   = map[string]int{}
+		mappings    strings.Builder
+		line        int
+		prevColumn  int
+		prev        [4]int // source, source line, source column, name
+		first       = true
+	)
+
+	for _, e := range deduped {
+		for line < e.at.Line {
+			mappings.WriteByte(';')
+			line++
+			prevColumn = 0
+			first = true
+		}
+
+		if !first {
+			mappings.WriteByte(',')
+		}
+		first = false
+
+		writeVLQ(&mappings, e.at.UTF16Column-prevColumn)
+		prevColumn = e.at.UTF16Column
+
+		if !e.mapped {
+			continue
+		}
+
+		src, ok := sourceIndex[e.loc.Source]
+		if !ok {
+			src = len(sources)
+			sourceIndex[e.loc.Source] = src
+			sources = append(sources, e.loc.Source)
+		}
+
+		writeVLQ(&mappings, src-prev[0])
+		writeVLQ(&mappings, e.loc.Line-prev[1])
+		writeVLQ(&mappings, e.loc.Column-prev[2])
+		prev[0], prev[1], prev[2] = src, e.loc.Line, e.loc.Column
+
+		if e.loc.Name != "" {
+			name, ok := nameIndex[e.loc.Name]
+			if !ok {
+				name = len(names)
+				nameIndex[e.loc.Name] = name
+				names = append(names, e.loc.Name)
+			}
+
+			writeVLQ(&mappings, name-prev[3])
+			prev[3] = name
+		}
+	}
+
+	if sources == nil {
+		sources = []string{}
+	}
+	if names == nil {
+		names = []string{}
+	}
+
+	return json.Marshal(struct {
+		Version  int      `json:"version"`
+		File     string   `json:"file"`
+		Sources  []string `json:"sources"`
+		Names    []string `json:"names"`
+		Mappings string   `json:"mappings"`
+	}{
+		Version:  3,
+		File:     file,
+		Sources:  sources,
+		Names:    names,
+		Mappings: mappings.String(),
+	})
+}
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// writeVLQ writes the number as a base64 variable-length quantity: the sign in the lowest bit,
+// then five bits per digit, least significant first, with the sixth bit set on all digits but the last.
+func writeVLQ(b *strings.Builder, n int) {
+	v := n << 1
+	if n < 0 {
+		v = -n<<1 | 1
+	}
+
+	for {
+		digit := v & 0x1f
+		v >>= 5
+		if v > 0 {
+			digit |= 0x20
+		}
+		b.WriteByte(base64Digits[digit])
+
+		if v == 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package pprint_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takoeight0821/pprint"
+)
+
+func TestRenderSpans(t *testing.T) {
+	t.Parallel()
+
+	doc := pprint.Mark("func", pprint.Vsep(
+		pprint.Nest(4, pprint.Vsep(
+			pprint.Hsep(pprint.Text("func"), pprint.Mark("name", pprint.Text("f")), pprint.Text("{")),
+			pprint.Hcat(pprint.Text("s := \"😀\" + "), pprint.Mark("x", pprint.Char('x'))),
+		)),
+		pprint.Text("}"),
+	))
+
+	got, spans := pprint.RenderSpans(doc, pprint.DefaultRenderOptions())
+
+	want := "func f {\n    s := \"😀\" + x\n}"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+
+	wantSpans := []pprint.Span{
+		{
+			ID:    "func",
+			Start: pprint.Position{},
+			End:   pprint.Position{Offset: len(want), Line: 2, Column: 1, UTF16Column: 1},
+		},
+		{
+			ID:    "name",
+			Start: pprint.Position{Offset: 5, Column: 5, UTF16Column: 5},
+			End:   pprint.Position{Offset: 6, Column: 6, UTF16Column: 6},
+		},
+		{
+			ID:    "x",
+			Start: pprint.Position{Offset: 27, Line: 1, Column: 18, UTF16Column: 16},
+			End:   pprint.Position{Offset: 28, Line: 1, Column: 19, UTF16Column: 17},
+		},
+	}
+	if diff := cmp.Diff(wantSpans, spans); diff != "" {
+		t.Errorf("spans mismatch (-want +got):\n%s", diff)
+	}
+
+	if got := want[spans[2].Start.Offset:spans[2].End.Offset]; got != "x" {
+		t.Errorf("the span of x covers %q", got)
+	}
+}
+
+func TestSplitSpans(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		doc      pprint.Doc
+		want     string
+		spans    []pprint.Span
+		mappings string
+	}{
+		{
+			name: "Tree",
+			doc:  pprint.Tree(pprint.Text("root"), pprint.Mark("child", pprint.Text("line1\nline2"))),
+			want: "root\n└── line1\n    line2",
+			spans: []pprint.Span{
+				{
+					ID:    "child",
+					Start: pprint.Position{Offset: 15, Line: 1, Column: 10, UTF16Column: 4},
+					End:   pprint.Position{Offset: 30, Line: 2, Column: 9, UTF16Column: 9},
+				},
+			},
+			mappings: ";IACA;S",
+		},
+		{
+			name: "Table",
+			doc: pprint.Table([][]pprint.Doc{
+				{pprint.Mark("a", pprint.Text("a1\na2")), pprint.Mark("b", pprint.Text("b1\nb2"))},
+			}, pprint.TableOptions{}),
+			want: "a1 | b1\na2 | b2",
+			spans: []pprint.Span{
+				{
+					ID:    "a",
+					Start: pprint.Position{},
+					End:   pprint.Position{Offset: 10, Line: 1, Column: 2, UTF16Column: 2},
+				},
+				{
+					ID:    "b",
+					Start: pprint.Position{Offset: 5, Column: 5, UTF16Column: 5},
+					End:   pprint.Position{Offset: 15, Line: 1, Column: 7, UTF16Column: 7},
+				},
+			},
+			// Where the cells overlap, the later one wins: "a2" is mapped to b.
+			mappings: "AAEA,KACA;EAAA,K",
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, spans := pprint.RenderSpans(test.doc, pprint.DefaultRenderOptions())
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(test.spans, spans); diff != "" {
+				t.Errorf("spans mismatch (-want +got):\n%s", diff)
+			}
+
+			data, err := pprint.EncodeSourceMap("out.go", spans, func(id any) (pprint.SourceLocation, bool) {
+				return pprint.SourceLocation{Source: "main.src", Line: map[any]int{"child": 1, "a": 2, "b": 3}[id]}, true
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var sourceMap map[string]any
+			if err := json.Unmarshal(data, &sourceMap); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.mappings, sourceMap["mappings"]); diff != "" {
+				t.Errorf("mappings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEncodeSourceMap(t *testing.T) {
+	t.Parallel()
+
+	origins := map[string]pprint.SourceLocation{
+		"func": {Source: "main.src", Line: 0, Column: 0},
+		"name": {Source: "main.src", Line: 0, Column: 3, Name: "f"},
+		"x":    {Source: "lib.src", Line: 40, Column: 2},
+	}
+
+	doc := pprint.Vsep(
+		pprint.Mark("func", pprint.Vsep(
+			pprint.Nest(4, pprint.Vsep(
+				pprint.Hsep(pprint.Text("func"), pprint.Mark("name", pprint.Text("f")), pprint.Text("{")),
+				pprint.Hcat(pprint.Text("return "), pprint.Mark("x", pprint.Char('x'))),
+			)),
+			pprint.Text("}"),
+		)),
+		pprint.Mark("unknown", pprint.Text("trailer")),
+	)
+
+	_, spans := pprint.RenderSpans(doc, pprint.DefaultRenderOptions())
+
+	data, err := pprint.EncodeSourceMap("out.go", spans, func(id any) (pprint.SourceLocation, bool) {
+		loc, ok := origins[id.(string)]
+		return loc, ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// Line 0: "func" from main.src 0:0, "f" from main.src 0:3 named f, back to "func" after it.
+	// Line 1: "x" at column 11 from lib.src 40:2, back to "func" after it.
+	// Line 2: unmapped after "}", up to the end of the output.
+	want := map[string]any{
+		"version":  float64(3),
+		"file":     "out.go",
+		"sources":  []any{"main.src", "lib.src"},
+		"names":    []any{"f"},
+		"mappings": "AAAA,KAAGA,CAAH;WCwCE,CDxCF;C",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("source map mismatch (-want +got):\n%s", diff)
+	}
+}
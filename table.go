@@ -0,0 +1,421 @@
+package pprint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Alignment is the horizontal alignment of the cells of a table column.
+type Alignment int
+
+const (
+	// AlignLeft pads cells on the right.
+	AlignLeft Alignment = iota
+	// AlignRight pads cells on the left.
+	AlignRight
+	// AlignCenter pads cells on both sides, with the odd space on the right.
+	AlignCenter
+)
+
+// TableOverflow selects what `Table` does with cells when the table is wider than the page.
+type TableOverflow int
+
+const (
+	// TableWrap lays out the cells at a narrower width, so the groups in them break into several lines.
+	// Columns never get narrower than the widest line of their cells with all groups broken.
+	TableWrap TableOverflow = iota
+	// TableTruncate cuts the lines of cells that are wider than their column and marks the cut with an ellipsis.
+	TableTruncate
+)
+
+// TableOptions configures `Table`.
+type TableOptions struct {
+	// Align gives the alignment of each column. Columns without an entry are aligned left.
+	Align []Alignment
+
+	// Header marks the first row as a header, which is underlined by a rule and names the fields of the vertical layout.
+	Header bool
+
+	// Separator is placed between the columns of a row. The zero value means " | ".
+	Separator string
+
+	// Overflow selects how cells are narrowed when the table does not fit the page.
+	Overflow TableOverflow
+}
+
+// minTruncatedWidth is the narrowest column `TableTruncate` cuts cells to.
+const minTruncatedWidth = 3
+
+// Table lays out rows of cells with the columns aligned to the width of their widest cell.
+// The table starts at the current column, and its rows after the first are aligned to it.
+//
+//	Table([][]Doc{
+//		{Text("name"), Text("age")},
+//		{Text("Alice"), Int(30)},
+//	}, TableOptions{Header: true, Align: []Alignment{AlignLeft, AlignRight}})
+//
+// will render as:
+//
+//	name  | age
+//	------+----
+//	Alice |  30
+//
+// If the table is wider than the page or ribbon, its columns are narrowed as selected by `TableOptions.Overflow`.
+// If even the narrowest columns do not fit, the rows are laid out vertically as records, one field per line,
+// with the header cells, or else the column numbers, as field names.
+func Table(rows [][]Doc, opts TableOptions) Doc {
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	if columns == 0 {
+		return Empty()
+	}
+
+	if opts.Separator == "" {
+		opts.Separator = " | "
+	}
+
+	return WithOptions(func(ropts RenderOptions) Doc {
+		return Column(func(k int) Doc {
+			t := table{rows: rows, columns: columns, opts: opts, ropts: ropts}
+
+			available := -1
+			if ropts.PageWidth >= 0 {
+				available = min(ropts.PageWidth, k+ropts.ribbonWidth()) - k
+			}
+
+			widths, ok := t.columnWidths(available)
+			if !ok {
+				return t.records()
+			}
+
+			return Align(t.grid(widths))
+		})
+	})
+}
+
+type table struct {
+	rows    [][]Doc
+	columns int
+	opts    TableOptions
+	ropts   RenderOptions
+}
+
+// cell returns the cell of the row in the column, or an empty document for a short row.
+func (t table) cell(row []Doc, c int) Doc {
+	if c < len(row) {
+		return row[c]
+	}
+
+	return Empty()
+}
+
+func (t table) align(c int) Alignment {
+	if c < len(t.opts.Align) {
+		return t.opts.Align[c]
+	}
+
+	return AlignLeft
+}
+
+// columnWidths returns the widths of the columns for a table that fits in available columns, or an unbounded width if it is negative.
+// It reports false if the columns cannot be narrowed enough.
+func (t table) columnWidths(available int) ([]int, bool) {
+	natural := make([]int, t.columns)
+	narrowest := make([]int, t.columns)
+
+	for _, row := range t.rows {
+		for c := 0; c < t.columns; c++ {
+			natural[c] = max(natural[c], t.ropts.flatWidth(t.cell(row, c)))
+
+			if t.opts.Overflow == TableTruncate {
+				narrowest[c] = min(natural[c], minTruncatedWidth)
+			} else {
+				narrowest[c] = max(narrowest[c], t.brokenWidth(t.cell(row, c)))
+			}
+		}
+	}
+
+	if available < 0 {
+		return natural, true
+	}
+
+	available -= (t.columns - 1) * StringWidth(t.opts.Separator)
+
+	total, least := 0, 0
+	for c := range natural {
+		total += natural[c]
+		least += narrowest[c]
+	}
+
+	if total <= available {
+		return natural, true
+	}
+
+	if least > available {
+		return nil, false
+	}
+
+	widths := append([]int(nil), narrowest...)
+	for rest := available - least; rest > 0; {
+		grown := false
+		for c := range widths {
+			if rest > 0 && widths[c] < natural[c] {
+				widths[c]++
+				rest--
+				grown = true
+			}
+		}
+
+		if !grown {
+			break
+		}
+	}
+
+	return widths, true
+}
+
+// brokenWidth returns the width of the widest line of the document with all its groups broken.
+func (t table) brokenWidth(doc Doc) int {
+	widest := 0
+	for _, l := range t.lines(doc, 0) {
+		widest = max(widest, l.width)
+	}
+
+	return widest
+}
+
+// grid lays out the rows with the given column widths.
+func (t table) grid(widths []int) Doc {
+	var lines []Doc
+
+	for r, row := range t.rows {
+		cells := make([][]docLine, t.columns)
+		height := 0
+		for c := range cells {
+			cells[c] = t.lines(t.cell(row, c), widths[c])
+			height = max(height, len(cells[c]))
+		}
+
+		for j := 0; j < height; j++ {
+			parts := make([]Doc, 0, 2*t.columns)
+			for c := range cells {
+				l := docLine{doc: Empty()}
+				if j < len(cells[c]) {
+					l = cells[c][j]
+				}
+
+				last := c == t.columns-1
+				if c > 0 {
+					sep := t.opts.Separator
+					if last && l.width == 0 {
+						sep = strings.TrimRight(sep, " ")
+					}
+					parts = append(parts, Text(sep))
+				}
+
+				parts = append(parts, t.pad(l, widths[c], t.align(c), last))
+			}
+
+			lines = append(lines, Hcat(parts...))
+		}
+
+		if r == 0 && t.opts.Header {
+			lines = append(lines, Text(t.rule(widths)))
+		}
+	}
+
+	return mergeDocs(lines, func(a, b Doc) Doc {
+		return Beside(a, Beside(line{}, b))
+	})
+}
+
+// pad pads the line of a cell to the column width. The last column is not padded on the right.
+func (t table) pad(l docLine, width int, align Alignment, last bool) Doc {
+	extra := max(0, width-l.width)
+
+	left := 0
+	switch align {
+	case AlignRight:
+		left = extra
+	case AlignCenter:
+		left = extra / 2
+	}
+
+	right := extra - left
+	if last {
+		right = 0
+	}
+
+	return Hcat(Text(Spaces(left)), l.doc, Text(Spaces(right)))
+}
+
+// rule returns the line under the header: dashes under the cells and the separator with its spaces turned into dashes and the rest into pluses.
+func (t table) rule(widths []int) string {
+	sep := strings.Map(func(r rune) rune {
+		if r == ' ' {
+			return '-'
+		}
+
+		return '+'
+	}, t.opts.Separator)
+
+	parts := make([]string, len(widths))
+	for c, w := range widths {
+		parts[c] = strings.Repeat("-", w)
+	}
+
+	return strings.Join(parts, sep)
+}
+
+// records lays out every row after the header as a list of fields, with a blank line between the rows.
+// A header without rows after it is laid out as a row itself, so that it is not lost.
+func (t table) records() Doc {
+	body := t.rows
+	names := make([]Doc, t.columns)
+	for c := range names {
+		names[c] = Int(c + 1)
+	}
+
+	if t.opts.Header && len(t.rows) > 1 {
+		for c := range names {
+			names[c] = t.cell(t.rows[0], c)
+		}
+		body = t.rows[1:]
+	}
+
+	nameWidth := 0
+	for _, n := range names {
+		nameWidth = max(nameWidth, t.ropts.flatWidth(n)+1)
+	}
+
+	records := make([]Doc, len(body))
+	for r, row := range body {
+		fields := make([]Doc, t.columns)
+		for c := range fields {
+			fields[c] = Hsep(Fill(nameWidth, Beside(names[c], Char(':'))), Align(t.cell(row, c)))
+		}
+
+		records[r] = mergeDocs(fields, func(a, b Doc) Doc {
+			return Beside(a, Beside(line{}, b))
+		})
+	}
+
+	return Align(mergeDocs(records, func(a, b Doc) Doc {
+		return Hcat(a, line{Literal: true}, line{}, b)
+	}))
+}
+
+// lines lays out a cell at the column width and splits it into lines.
+func (t table) lines(doc Doc, width int) []docLine {
+	return layoutLines(t.ropts, doc, width, t.opts.Overflow == TableTruncate)
+}
+
+// docLine is one line of a laid out document and its width.
+type docLine struct {
+	doc   Doc
+	width int
+}
+
+// layoutLines lays out the document at the given page width, or `Unbounded`, and splits it into lines.
+// If truncate is set, the lines wider than the page are cut. Annotations are closed at the end of
+// each line and reopened on the next one, so every line can be placed on its own;
+// `DisplaySpans` still reports a marked document split this way as one span.
+func layoutLines(opts RenderOptions, doc Doc, width int, truncate bool) []docLine {
+	measure, tabWidth := opts.measure(), opts.tabWidth()
+
+	opts.PageWidth = width
+	opts.RibbonFraction = 1
+
+	truncate = truncate && width > 0
+
+	type frame struct {
+		ann   any
+		parts []Doc
+	}
+
+	var (
+		lines  []docLine
+		frames = []frame{{}}
+		w      int
+		cut    bool
+	)
+
+	add := func(s string) {
+		if cut {
+			return
+		}
+
+		if l := advanceColumn(w, s, measure, tabWidth) - w; !truncate || w+l <= width {
+			frames[len(frames)-1].parts = append(frames[len(frames)-1].parts, Text(s))
+			w += l
+
+			return
+		}
+
+		prefix := ""
+		for _, r := range s {
+			if advanceColumn(w, prefix+string(r), measure, tabWidth)+measure("…") > width {
+				break
+			}
+			prefix += string(r)
+		}
+
+		frames[len(frames)-1].parts = append(frames[len(frames)-1].parts, Text(prefix+"…"))
+		w = advanceColumn(w, prefix+"…", measure, tabWidth)
+		cut = true
+	}
+
+	closeFrames := func() Doc {
+		for j := len(frames) - 1; j > 0; j-- {
+			frames[j-1].parts = append(frames[j-1].parts, Annotate(frames[j].ann, Hcat(frames[j].parts...)))
+		}
+
+		return Hcat(frames[0].parts...)
+	}
+
+	reopen := func() {
+		for j := range frames {
+			frames[j].parts = nil
+		}
+	}
+
+	for x := Render(opts, doc); ; {
+		switch d := x.(type) {
+		case SEmpty:
+			return append(lines, docLine{doc: closeFrames(), width: w})
+		case SChar:
+			add(string(d.char))
+			x = d.rest
+		case SText:
+			add(d.text)
+			x = d.rest
+		case SLine:
+			lines = append(lines, docLine{doc: closeFrames(), width: w})
+			reopen()
+			w, cut = 0, false
+			add(Spaces(max(d.indent, 0)))
+			x = d.rest
+		case SAnnPush:
+			ann := d.ann
+			if m, ok := ann.(Marker); ok && m.whole == nil {
+				// The parts of the marked document on every line form one span.
+				m.whole = new(int)
+				ann = m
+			}
+			frames = append(frames, frame{ann: ann})
+			x = d.rest
+		case SAnnPop:
+			if len(frames) > 1 {
+				top := frames[len(frames)-1]
+				frames = frames[:len(frames)-1]
+				frames[len(frames)-1].parts = append(frames[len(frames)-1].parts, Annotate(top.ann, Hcat(top.parts...)))
+			}
+			x = d.rest
+		default:
+			panic(fmt.Sprintf("unexpected pprint.SimpleDoc: %#v", d))
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package pprint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takoeight0821/pprint"
+)
+
+func TestTable(t *testing.T) {
+	t.Parallel()
+
+	people := [][]pprint.Doc{
+		{pprint.Text("name"), pprint.Text("age"), pprint.Text("city")},
+		{pprint.Text("Alice"), pprint.Int(30), pprint.Text("Tokyo")},
+		{pprint.Text("Bob"), pprint.Int(4), pprint.Text("Osaka")},
+	}
+
+	tests := []struct {
+		name  string
+		width int
+		doc   pprint.Doc
+		want  []string
+	}{
+		{
+			name:  "Header",
+			width: 80,
+			doc: pprint.Table(people, pprint.TableOptions{
+				Header: true,
+				Align:  []pprint.Alignment{pprint.AlignLeft, pprint.AlignRight},
+			}),
+			want: []string{
+				"name  | age | city",
+				"------+-----+------",
+				"Alice |  30 | Tokyo",
+				"Bob   |   4 | Osaka",
+			},
+		},
+		{
+			name:  "Center And Separator",
+			width: 80,
+			doc: pprint.Table(people[1:], pprint.TableOptions{
+				Separator: "  ",
+				Align:     []pprint.Alignment{pprint.AlignCenter, pprint.AlignCenter, pprint.AlignRight},
+			}),
+			want: []string{
+				"Alice  30  Tokyo",
+				" Bob   4   Osaka",
+			},
+		},
+		{
+			name:  "Ragged Rows",
+			width: 80,
+			doc: pprint.Table([][]pprint.Doc{
+				{pprint.Text("a")},
+				{pprint.Text("bb"), pprint.Text("c")},
+			}, pprint.TableOptions{}),
+			want: []string{
+				"a  |",
+				"bb | c",
+			},
+		},
+		{
+			name:  "Aligned To Column",
+			width: 80,
+			doc:   pprint.Hsep(pprint.Text("table:"), pprint.Table(people[1:], pprint.TableOptions{})),
+			want: []string{
+				"table: Alice | 30 | Tokyo",
+				"       Bob   | 4  | Osaka",
+			},
+		},
+		{
+			name:  "Wrap",
+			width: 20,
+			doc: pprint.Table([][]pprint.Doc{
+				{pprint.Text("key"), pprint.Text("description")},
+				{pprint.Text("a"), pprint.Reflow("the first letter of the alphabet")},
+			}, pprint.TableOptions{Header: true}),
+			want: []string{
+				"key | description",
+				"----+---------------",
+				"a   | the first",
+				"    | letter of the",
+				"    | alphabet",
+			},
+		},
+		{
+			name:  "Truncate",
+			width: 16,
+			doc: pprint.Table([][]pprint.Doc{
+				{pprint.Text("key"), pprint.Text("description")},
+				{pprint.Text("a"), pprint.Text("the first letter")},
+			}, pprint.TableOptions{Header: true, Overflow: pprint.TableTruncate}),
+			want: []string{
+				"key | descripti…",
+				"----+-----------",
+				"a   | the first…",
+			},
+		},
+		{
+			name:  "Records",
+			width: 12,
+			doc: pprint.Table(people, pprint.TableOptions{
+				Header: true,
+			}),
+			want: []string{
+				"name: Alice",
+				"age:  30",
+				"city: Tokyo",
+				"",
+				"name: Bob",
+				"age:  4",
+				"city: Osaka",
+			},
+		},
+		{
+			name:  "Records Without Header",
+			width: 8,
+			doc: pprint.Table([][]pprint.Doc{
+				{pprint.Text("abcdef"), pprint.Text("ghijkl")},
+			}, pprint.TableOptions{}),
+			want: []string{
+				"1: abcdef",
+				"2: ghijkl",
+			},
+		},
+		{
+			name:  "Records Of Header Only",
+			width: 8,
+			doc: pprint.Table([][]pprint.Doc{
+				{pprint.Text("abcdef"), pprint.Text("ghijkl")},
+			}, pprint.TableOptions{Header: true}),
+			want: []string{
+				"1: abcdef",
+				"2: ghijkl",
+			},
+		},
+		{
+			name:  "Empty",
+			width: 80,
+			doc:   pprint.Table(nil, pprint.TableOptions{}),
+			want:  []string{""},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.Display(&got, pprint.RenderPretty(1, test.width, test.doc)); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, strings.Split(got.String(), "\n")); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTableAnnotations(t *testing.T) {
+	t.Parallel()
+
+	bold := pprint.Style{Bold: true}
+	doc := pprint.Table([][]pprint.Doc{
+		{pprint.Text("k"), pprint.Styled(bold, pprint.Reflow("one two"))},
+	}, pprint.TableOptions{})
+
+	var got strings.Builder
+	if err := pprint.DisplayHTML(&got, pprint.RenderPretty(1, 7, doc), pprint.HTMLOptions{
+		Tag: func(any) (string, string) { return "<b>", "</b>" },
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "<pre>k | <b>one</b>\n  | <b>two</b></pre>"
+	if diff := cmp.Diff(want, got.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
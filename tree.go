@@ -0,0 +1,84 @@
+package pprint
+
+import "strings"
+
+// TreeGuides are the prefixes `TreeWith` draws before the lines of the children of a tree.
+// All prefixes should be equally wide.
+type TreeGuides struct {
+	// Branch is put before the first line of every child but the last.
+	Branch string
+	// Last is put before the first line of the last child.
+	Last string
+	// Vertical is put before the other lines of every child but the last, continuing the guide to the next child.
+	Vertical string
+	// Space is put before the other lines of the last child.
+	Space string
+}
+
+var (
+	// UnicodeTreeGuides draws the guides with box-drawing characters, like tree(1).
+	UnicodeTreeGuides = TreeGuides{Branch: "├── ", Last: "└── ", Vertical: "│   ", Space: "    "}
+
+	// ASCIITreeGuides draws the guides with ASCII characters only.
+	ASCIITreeGuides = TreeGuides{Branch: "|-- ", Last: "`-- ", Vertical: "|   ", Space: "    "}
+)
+
+// Tree lays out the label followed by the children below it, each drawn with `UnicodeTreeGuides`.
+// Children can be trees themselves.
+//
+//	Tree(Text("root"), Tree(Text("a"), Text("a1")), Text("b"))
+//
+// will render as:
+//
+//	root
+//	├── a
+//	│   └── a1
+//	└── b
+func Tree(label Doc, children ...Doc) Doc {
+	return TreeWith(UnicodeTreeGuides, label, children...)
+}
+
+// TreeWith is `Tree` with the given guides.
+//
+// The children are laid out on their own at the width left after the guides, and every line
+// of a child that spans several lines gets a guide, so the guide to the next child is not interrupted.
+// The lines of the tree break at the current indentation level.
+func TreeWith(guides TreeGuides, label Doc, children ...Doc) Doc {
+	if len(children) == 0 {
+		return label
+	}
+
+	return WithOptions(func(opts RenderOptions) Doc {
+		return Nesting(func(i int) Doc {
+			guideWidth := opts.measure()(guides.Branch)
+
+			width := Unbounded
+			if opts.PageWidth >= 0 {
+				width = max(0, min(opts.PageWidth, i+opts.ribbonWidth())-i-guideWidth)
+			}
+
+			parts := []Doc{label}
+			for c, child := range children {
+				first, rest := guides.Branch, guides.Vertical
+				if c == len(children)-1 {
+					first, rest = guides.Last, guides.Space
+				}
+
+				for j, l := range layoutLines(opts, child, width, false) {
+					prefix := first
+					if j > 0 {
+						prefix = rest
+					}
+
+					if l.width == 0 {
+						prefix = strings.TrimRight(prefix, " ")
+					}
+
+					parts = append(parts, line{}, Text(prefix), l.doc)
+				}
+			}
+
+			return Hcat(parts...)
+		})
+	})
+}
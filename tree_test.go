@@ -0,0 +1,131 @@
+package pprint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takoeight0821/pprint"
+)
+
+func TestTree(t *testing.T) {
+	t.Parallel()
+
+	deps := pprint.Tree(pprint.Text("app"),
+		pprint.Tree(pprint.Text("net"),
+			pprint.Text("tls"),
+			pprint.Text("dns"),
+		),
+		pprint.Text("log"),
+	)
+
+	tests := []struct {
+		name  string
+		width int
+		doc   pprint.Doc
+		want  []string
+	}{
+		{
+			name:  "Unicode",
+			width: 80,
+			doc:   deps,
+			want: []string{
+				"app",
+				"├── net",
+				"│   ├── tls",
+				"│   └── dns",
+				"└── log",
+			},
+		},
+		{
+			name:  "ASCII",
+			width: 80,
+			doc: pprint.TreeWith(pprint.ASCIITreeGuides, pprint.Text("app"),
+				pprint.TreeWith(pprint.ASCIITreeGuides, pprint.Text("net"), pprint.Text("tls")),
+				pprint.Text("log"),
+			),
+			want: []string{
+				"app",
+				"|-- net",
+				"|   `-- tls",
+				"`-- log",
+			},
+		},
+		{
+			name:  "Leaf",
+			width: 80,
+			doc:   pprint.Tree(pprint.Text("alone")),
+			want:  []string{"alone"},
+		},
+		{
+			name:  "Multi-line Children",
+			width: 80,
+			doc: pprint.Tree(pprint.Text("root"),
+				pprint.Text("first\nsecond\n\nfourth"),
+				pprint.Text("last\nline"),
+			),
+			want: []string{
+				"root",
+				"├── first",
+				"│   second",
+				"│",
+				"│   fourth",
+				"└── last",
+				"    line",
+			},
+		},
+		{
+			name:  "Children Wrap At Remaining Width",
+			width: 14,
+			doc: pprint.Tree(pprint.Text("root"),
+				pprint.Reflow("one two three four"),
+				pprint.Text("x"),
+			),
+			want: []string{
+				"root",
+				"├── one two",
+				"│   three four",
+				"└── x",
+			},
+		},
+		{
+			name:  "Negative Nest",
+			width: 80,
+			doc:   pprint.Tree(pprint.Text("r"), pprint.Nest(-3, pprint.Vsep(pprint.Text("a"), pprint.Text("b")))),
+			want: []string{
+				"r",
+				"└── a",
+				"    b",
+			},
+		},
+		{
+			name:  "Nest",
+			width: 80,
+			doc:   pprint.Nest(2, pprint.Vsep(pprint.Text("deps:"), deps)),
+			want: []string{
+				"deps:",
+				"  app",
+				"  ├── net",
+				"  │   ├── tls",
+				"  │   └── dns",
+				"  └── log",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.Display(&got, pprint.RenderPretty(1, test.width, test.doc)); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, strings.Split(got.String(), "\n")); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
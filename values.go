@@ -0,0 +1,72 @@
+package pprint
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// integer is the set of integer types accepted by `Integer`.
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Int renders an int in decimal.
+func Int(n int) Doc {
+	return Text(strconv.Itoa(n))
+}
+
+// Int64 renders an int64 in decimal.
+func Int64(n int64) Doc {
+	return Text(strconv.FormatInt(n, 10))
+}
+
+// Uint renders a uint in decimal.
+func Uint(n uint) Doc {
+	return Text(strconv.FormatUint(uint64(n), 10))
+}
+
+// Integer renders a value of any integer type in decimal.
+func Integer[T integer](n T) Doc {
+	if n < 0 {
+		return Text(strconv.FormatInt(int64(n), 10))
+	}
+
+	return Text(strconv.FormatUint(uint64(n), 10))
+}
+
+// Float renders a float64 with the format and precision of `strconv.FormatFloat`.
+//
+//	Float(3.14159, 'f', 2)
+//
+// will render as `3.14`.
+func Float(f float64, fmt byte, prec int) Doc {
+	return Text(strconv.FormatFloat(f, fmt, prec, 64))
+}
+
+// Bool renders a bool as `true` or `false`.
+func Bool(b bool) Doc {
+	return Text(strconv.FormatBool(b))
+}
+
+// Rune renders a rune as a single-quoted Go character literal.
+func Rune(r rune) Doc {
+	return Text(strconv.QuoteRune(r))
+}
+
+// Stringer renders the result of the String method of s.
+func Stringer(s fmt.Stringer) Doc {
+	return Text(s.String())
+}
+
+// Any renders v with its Pretty method if it implements `Pretty`, returns v itself if it is a Doc,
+// and falls back to formatting it with `%v` otherwise.
+func Any(v any) Doc {
+	switch v := v.(type) {
+	case Pretty:
+		return v.Pretty()
+	case Doc:
+		return v
+	default:
+		return Text(fmt.Sprintf("%v", v))
+	}
+}
@@ -0,0 +1,68 @@
+package pprint_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takoeight0821/pprint"
+)
+
+type point struct {
+	X, Y int
+}
+
+func (p point) Pretty() pprint.Doc {
+	return pprint.Tupled(pprint.Int(p.X), pprint.Int(p.Y))
+}
+
+type level uint8
+
+func TestValues(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		doc  pprint.Doc
+		want string
+	}{
+		{name: "Int", doc: pprint.Int(-42), want: "-42"},
+		{name: "Int64", doc: pprint.Int64(-1 << 62), want: "-4611686018427387904"},
+		{name: "Uint", doc: pprint.Uint(42), want: "42"},
+		{name: "Integer Signed", doc: pprint.Integer(int8(-128)), want: "-128"},
+		{name: "Integer Unsigned", doc: pprint.Integer(uint64(1<<64 - 1)), want: "18446744073709551615"},
+		{name: "Integer Named", doc: pprint.Integer(level(7)), want: "7"},
+		{name: "Float Fixed", doc: pprint.Float(3.14159, 'f', 2), want: "3.14"},
+		{name: "Float Shortest", doc: pprint.Float(0.1, 'g', -1), want: "0.1"},
+		{name: "Float Exponent", doc: pprint.Float(1234.5, 'e', 3), want: "1.234e+03"},
+		{name: "Bool", doc: pprint.Hsep(pprint.Bool(true), pprint.Bool(false)), want: "true false"},
+		{name: "Rune", doc: pprint.Rune('a'), want: "'a'"},
+		{name: "Rune Escaped", doc: pprint.Rune('\n'), want: `'\n'`},
+		{name: "Stringer", doc: pprint.Stringer(1500 * time.Millisecond), want: "1.5s"},
+		{name: "Any Pretty", doc: pprint.Any(point{X: 1, Y: 2}), want: "(1,2)"},
+		{name: "Any Doc", doc: pprint.Any(pprint.Text("doc")), want: "doc"},
+		{name: "Any Fallback", doc: pprint.Any([]int{1, 2}), want: "[1 2]"},
+		{
+			name: "Mixed",
+			doc:  pprint.Hsep(pprint.Any("x"), pprint.Any(1), pprint.Any(2.5), pprint.Any(point{X: 3, Y: 4})),
+			want: "x 1 2.5 (3,4)",
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.FputDoc(&got, test.doc); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, got.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package pprint_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takoeight0821/pprint"
+)
+
+func TestStringWidth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{s: "", want: 0},
+		{s: "hello", want: 5},
+		{s: "名前", want: 4},
+		{s: "ｱｲｳ", want: 3},
+		{s: "ＡＢ", want: 4},
+		{s: "café", want: 4},
+		{s: "cafe\u0301", want: 4},
+		{s: "한국어", want: 6},
+		{s: "\u1100\u1161\u11a8", want: 2},
+		{s: "👍", want: 2},
+		{s: "👍🏽", want: 2},
+		{s: "👨\u200d👩\u200d👧", want: 2},
+		{s: "🇯🇵", want: 2},
+		{s: "\u2764\ufe0f", want: 2},
+		{s: "a\tb", want: 2},
+	}
+
+	for _, test := range tests {
+		if got := pprint.StringWidth(test.s); got != test.want {
+			t.Errorf("StringWidth(%q) = %d, want %d", test.s, got, test.want)
+		}
+	}
+}
+
+func TestRenderWideText(t *testing.T) {
+	t.Parallel()
+
+	table := func(keys ...string) pprint.Doc {
+		rows := make([]pprint.Doc, len(keys))
+		for i, k := range keys {
+			rows[i] = pprint.Hsep(pprint.Fill(6, pprint.Text(k)), pprint.Text("="), pprint.Text("..."))
+		}
+
+		return pprint.Vsep(rows...)
+	}
+
+	tests := []struct {
+		name string
+		opts pprint.RenderOptions
+		doc  pprint.Doc
+		want []string
+	}{
+		{
+			name: "Fill",
+			opts: pprint.DefaultRenderOptions(),
+			doc:  table("名前", "年齢", "id"),
+			want: []string{
+				"名前   = ...",
+				"年齢   = ...",
+				"id     = ...",
+			},
+		},
+		{
+			name: "Align",
+			opts: pprint.DefaultRenderOptions(),
+			doc:  pprint.Hsep(pprint.Text("関数"), pprint.Align(pprint.Vsep(pprint.Text("a"), pprint.Text("b")))),
+			want: []string{
+				"関数 a",
+				"     b",
+			},
+		},
+		{
+			name: "Group",
+			opts: pprint.RenderOptions{PageWidth: 10},
+			doc:  pprint.Sep(pprint.Text("日本語"), pprint.Text("テキスト")),
+			want: []string{
+				"日本語",
+				"テキスト",
+			},
+		},
+		{
+			name: "Custom Width",
+			opts: pprint.RenderOptions{PageWidth: 80, TextWidth: func(s string) int { return len(s) }},
+			doc:  table("名前", "id"),
+			want: []string{
+				"名前 = ...",
+				"id     = ...",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.Display(&got, pprint.Render(test.opts, test.doc)); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, strings.Split(got.String(), "\n")); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTabs(t *testing.T) {
+	t.Parallel()
+
+	fields := pprint.Vsep(
+		pprint.Hsep(pprint.Fill(12, pprint.Text("a\tb")), pprint.Text("x")),
+		pprint.Hsep(pprint.Fill(12, pprint.Text("abc")), pprint.Text("y")),
+	)
+
+	block := pprint.Vsep(
+		pprint.Nest(10, pprint.Vsep(pprint.Text("func f() {"), pprint.Text("return"))),
+		pprint.Text("}"),
+	)
+
+	tests := []struct {
+		name string
+		opts pprint.RenderOptions
+		doc  pprint.Doc
+		want []string
+	}{
+		{
+			name: "Preserve",
+			opts: pprint.DefaultRenderOptions(),
+			doc:  fields,
+			want: []string{
+				"a\tb    x",
+				"abc          y",
+			},
+		},
+		{
+			name: "Expand",
+			opts: pprint.RenderOptions{PageWidth: 80, Tabs: pprint.TabsExpand, TabWidth: 4},
+			doc:  pprint.Hsep(pprint.Text("ab"), pprint.Align(pprint.Vsep(pprint.Text("\tc"), pprint.Text("d\te")))),
+			want: []string{
+				"ab  c",
+				"   d    e",
+			},
+		},
+		{
+			name: "Tab Stops Count Toward Fits",
+			opts: pprint.RenderOptions{PageWidth: 10},
+			doc:  pprint.Sep(pprint.Text("\ta"), pprint.Text("b")),
+			want: []string{
+				"\ta",
+				"b",
+			},
+		},
+		{
+			name: "Indent With Tabs",
+			opts: pprint.RenderOptions{PageWidth: 80, TabWidth: 4, IndentWithTabs: true},
+			doc:  block,
+			want: []string{
+				"func f() {",
+				"\t\t  return",
+				"}",
+			},
+		},
+		{
+			name: "Indent With Tabs Optimal",
+			opts: pprint.RenderOptions{PageWidth: 80, IndentWithTabs: true, Layout: pprint.LayoutOptimal},
+			doc:  block,
+			want: []string{
+				"func f() {",
+				"\t  return",
+				"}",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test // capture range variable
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got strings.Builder
+			if err := pprint.Display(&got, pprint.Render(test.opts, test.doc)); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.want, strings.Split(got.String(), "\n")); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}